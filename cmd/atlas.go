@@ -0,0 +1,98 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/hourglasshoro/auto-table/pkg/atlas"
+	"github.com/hourglasshoro/auto-table/pkg/file"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+)
+
+// atlasCmd represents the atlas command
+var atlasCmd = &cobra.Command{
+	Use:   "atlas",
+	Short: "Export the schema declared by the structs as an Atlas HCL file",
+	Long: `Build the schema declared by the structs in the source directory and print it
+as an Atlas HCL schema of the dialect, with the tables, columns, primary keys,
+foreign keys, indexes and table options. Use --file to write it to a file, to
+let Atlas plan and apply the migrations.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		conv, err := newConverter(cmd)
+		if err != nil {
+			return
+		}
+		tables, err := conv.Schema()
+		if err != nil {
+			return
+		}
+		dialectName := viper.GetString("dialect")
+		schema, _ := cmd.Flags().GetString("schema")
+		if schema == "" {
+			schema, err = defaultSchema(dialectName)
+			if err != nil {
+				return
+			}
+		}
+		b, err := atlas.Export(dialectName, tables, schema)
+		if err != nil {
+			return
+		}
+		filename, _ := cmd.Flags().GetString("file")
+		if filename == "" {
+			_, err = cmd.OutOrStdout().Write(b)
+			return
+		}
+		currentDir, err := os.Getwd()
+		if err != nil {
+			return
+		}
+		err = afero.WriteFile(afero.NewOsFs(), file.Solve(filename, currentDir), b, 0644)
+		return
+	},
+}
+
+// defaultSchema names the schema of the tables when --schema is not set.
+// A MySQL schema is a database, named by the dsn setting.
+func defaultSchema(dialectName string) (string, error) {
+	switch dialectName {
+	case "postgres":
+		return "public", nil
+	case "mysql":
+		if dsn := viper.GetString("dsn"); dsn != "" {
+			cfg, err := mysql.ParseDSN(dsn)
+			if err != nil {
+				return "", err
+			}
+			if cfg.DBName != "" {
+				return cfg.DBName, nil
+			}
+		}
+		return "", fmt.Errorf("auto-table: set --schema to the name of the database, or --dsn with the database")
+	}
+	return "main", nil
+}
+
+func init() {
+	rootCmd.AddCommand(atlasCmd)
+	atlasCmd.Flags().String("schema", "", "Name of the schema the tables belong to (default is public for postgres, the database of --dsn for mysql and main for sqlite)")
+	atlasCmd.Flags().String("file", "", "File to write the schema to instead of printing it, like schema.hcl")
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/file"
+	"github.com/hourglasshoro/auto-table/pkg/migration"
+	"github.com/hourglasshoro/auto-table/pkg/runner"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// openDB connects to the database given by the dsn setting, using the driver setting or the dialect name as the driver.
+// The drivers registered as mysql, postgres and sqlite are built in.
+func openDB() (db *sql.DB, d dialect.Dialect, err error) {
+	dsn := viper.GetString("dsn")
+	if dsn == "" {
+		err = fmt.Errorf("auto-table: no database given, set --dsn or the dsn config key")
+		return
+	}
+	d, err = dialect.New(viper.GetString("dialect"))
+	if err != nil {
+		return
+	}
+	driver := viper.GetString("driver")
+	if driver == "" {
+		driver = viper.GetString("dialect")
+	}
+	db, err = sql.Open(driver, dsn)
+	if err != nil {
+		return
+	}
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		db = nil
+	}
+	return
+}
+
+// inspect reads the tables of the database with the dialect.
+func inspect(db *sql.DB, d dialect.Dialect) ([]dialect.Table, error) {
+	inspector, ok := d.(dialect.Inspector)
+	if !ok {
+		return nil, fmt.Errorf("auto-table: the %s dialect cannot inspect databases", viper.GetString("dialect"))
+	}
+	return inspector.Inspect(db)
+}
+
+// newRunner reads the migrations in the output directory and connects to the database they are applied to.
+func newRunner(cmd *cobra.Command) (r *runner.Runner, db *sql.DB, err error) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot get current dir")
+	}
+	if format := viper.GetString("format"); format != migration.DefaultFormat {
+		return nil, nil, fmt.Errorf("auto-table: only %s migrations can be run, run %s migrations with %s itself", migration.DefaultFormat, format, format)
+	}
+	output := file.Solve(cmd.Flag("output").Value.String(), currentDir)
+	defaultFileSystem := afero.NewOsFs()
+	migrates, err := migration.ReadDir(&defaultFileSystem, output)
+	if err != nil {
+		return
+	}
+	db, d, err := openDB()
+	if err != nil {
+		return
+	}
+	r, err = runner.New(d, db, migrates)
+	if err != nil {
+		db.Close()
+		db = nil
+	}
+	return
+}
+
+// printMigrates prints the up or down file of each migration that was run.
+func printMigrates(cmd *cobra.Command, verb string, migrates []*migration.Migrate) {
+	for _, m := range migrates {
+		elm := m.Up
+		if verb != "applied" {
+			elm = m.Down
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", verb, elm.File)
+	}
+}
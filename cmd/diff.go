@@ -0,0 +1,41 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Generate ALTER migrations for the changes since the last snapshot",
+	Long: `Compare the current structs with the schema recorded in the snapshot file
+and write only the migrations needed to get from one to the other.
+The snapshot is updated afterwards, so the next run starts from this state.`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		conv, err := newConverter(cmd)
+		if err != nil {
+			return
+		}
+		err = conv.DiffSQL()
+		return
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
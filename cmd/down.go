@@ -0,0 +1,51 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"strconv"
+)
+
+// downCmd represents the down command
+var downCmd = &cobra.Command{
+	Use:   "down [N]",
+	Short: "Revert the last N applied migrations",
+	Long: `Run the down files of the last N migrations recorded in the schema_migrations
+table, newest first. Only the last migration is reverted if N is omitted.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		n := 1
+		if len(args) == 1 {
+			n, err = strconv.Atoi(args[0])
+			if err != nil {
+				return
+			}
+		}
+		r, db, err := newRunner(cmd)
+		if err != nil {
+			return
+		}
+		defer db.Close()
+		reverted, err := r.Down(n)
+		printMigrates(cmd, "reverted", reverted)
+		return
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(downCmd)
+}
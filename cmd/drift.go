@@ -0,0 +1,71 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/drift"
+	"github.com/hourglasshoro/auto-table/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+// driftCmd represents the drift command
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Report differences between the structs and a live database",
+	Long: `Build the schema declared by the structs in the source directory, inspect the
+database given by --dsn, and print every missing or extra table, column, index
+and foreign key, and every column whose type, nullability or default differs.
+Use --fail to exit with a non-zero status when there is any drift, for CI.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		conv, err := newConverter(cmd)
+		if err != nil {
+			return
+		}
+		model, err := conv.Schema()
+		if err != nil {
+			return
+		}
+		db, d, err := openDB()
+		if err != nil {
+			return
+		}
+		defer db.Close()
+		live, err := inspect(db, d)
+		if err != nil {
+			return
+		}
+
+		schemaDiff := drift.Detect(model, live, runner.TableName)
+		if schemaDiff.IsEmpty() {
+			fmt.Fprintln(cmd.OutOrStdout(), "no drift")
+			return
+		}
+		if err = drift.Report(cmd.OutOrStdout(), schemaDiff); err != nil {
+			return
+		}
+		if fail, _ := cmd.Flags().GetBool("fail"); fail {
+			err = fmt.Errorf("auto-table: the database has drifted from the structs")
+		}
+		return
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+	driftCmd.Flags().Bool("fail", false, "Exit with a non-zero status if there is any drift")
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/hourglasshoro/auto-table/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+// inspectCmd represents the inspect command
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Print the schema of a live database as a snapshot",
+	Long: `Read the tables, columns, primary keys, foreign keys and indexes of the
+database given by --dsn and print them in the schema snapshot format.`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		db, d, err := openDB()
+		if err != nil {
+			return
+		}
+		defer db.Close()
+		tables, err := inspect(db, d)
+		if err != nil {
+			return
+		}
+		b, err := snapshot.FromTables(tables).Marshal()
+		if err != nil {
+			return
+		}
+		_, err = cmd.OutOrStdout().Write(b)
+		return
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/ddl"
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/reverse"
+	"github.com/hourglasshoro/auto-table/pkg/runner"
+	"github.com/hourglasshoro/auto-table/pkg/snapshot"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// reverseCmd represents the reverse command
+var reverseCmd = &cobra.Command{
+	Use:   "reverse",
+	Short: "Write annotated structs for the tables of an existing database",
+	Long: `Inspect the database given by --dsn, read the schema snapshot with
+--from-snapshot, or parse MySQL or PostgreSQL CREATE TABLE statements with
+--ddl, and write one annotated struct per table into the source directory.
+Existing files are kept unless --force is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		conv, err := newConverter(cmd)
+		if err != nil {
+			return
+		}
+
+		var tables []dialect.Table
+		ddlFiles, _ := cmd.Flags().GetStringSlice("ddl")
+		if fromSnapshot, _ := cmd.Flags().GetBool("from-snapshot"); len(ddlFiles) > 0 {
+			tables, err = parseDDL(conv.FileSystem, ddlFiles)
+			if err != nil {
+				return
+			}
+		} else if fromSnapshot {
+			s, sErr := snapshot.Read(conv.FileSystem, conv.SnapshotFile)
+			if sErr != nil {
+				return sErr
+			}
+			tables = s.Schema()
+		} else {
+			db, d, dbErr := openDB()
+			if dbErr != nil {
+				return dbErr
+			}
+			defer db.Close()
+			tables, err = inspect(db, d)
+			if err != nil {
+				return
+			}
+		}
+		filtered := tables[:0]
+		for _, t := range tables {
+			if t.Name != runner.TableName {
+				filtered = append(filtered, t)
+			}
+		}
+
+		packageName, _ := cmd.Flags().GetString("package")
+		if packageName == "" {
+			packageName = strings.ToLower(strings.Map(func(r rune) rune {
+				if r == '-' || r == '.' {
+					return '_'
+				}
+				return r
+			}, filepath.Base(conv.SourceDir)))
+		}
+		files, err := reverse.Generate(conv.Dialect, filtered, packageName, conv.TagMaker)
+		if err != nil {
+			return
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		fs := *conv.FileSystem
+		if err = fs.MkdirAll(conv.SourceDir, 0755); err != nil {
+			return
+		}
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			filename := filepath.Join(conv.SourceDir, name)
+			if exists, _ := afero.Exists(fs, filename); exists && !force {
+				fmt.Fprintf(cmd.ErrOrStderr(), "skipped %s, it already exists\n", filename)
+				continue
+			}
+			if err = afero.WriteFile(fs, filename, files[name], 0644); err != nil {
+				return
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "wrote %s\n", filename)
+		}
+		return
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reverseCmd)
+	reverseCmd.Flags().Bool("from-snapshot", false, "Read the tables from the schema snapshot instead of the database")
+	reverseCmd.Flags().StringSlice("ddl", nil, "Read the tables from SQL files of CREATE TABLE statements instead of the database")
+	reverseCmd.Flags().String("package", "", "Package name of the written files (default is the name of the source directory)")
+	reverseCmd.Flags().Bool("force", false, "Overwrite existing files")
+}
+
+// parseDDL reads the tables from the SQL files. Later files can add indexes and constraints to the tables of earlier ones.
+func parseDDL(fs *afero.Fs, filenames []string) ([]dialect.Table, error) {
+	p := ddl.NewParser()
+	for _, filename := range filenames {
+		b, err := afero.ReadFile(*fs, filename)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.Parse(string(b)); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+	return p.Tables(), nil
+}
@@ -18,10 +18,14 @@ package cmd
 import (
 	"fmt"
 	"github.com/hourglasshoro/auto-table/pkg"
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
 	"github.com/hourglasshoro/auto-table/pkg/file"
+	"github.com/hourglasshoro/auto-table/pkg/migration"
+	"github.com/hourglasshoro/auto-table/pkg/snapshot"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"os"
+	"strings"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
@@ -39,24 +43,58 @@ examples and usage of using your application. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
+	// Errors are printed once by Execute, without the usage.
+	SilenceUsage:  true,
+	SilenceErrors: true,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		currentDir, err := os.Getwd()
+		conv, err := newConverter(cmd)
 		if err != nil {
-			return fmt.Errorf("cannot get current dir")
+			return
 		}
-		source := cmd.Flag("source").Value.String()
-		source = file.Solve(source, currentDir)
-		output := cmd.Flag("output").Value.String()
-		output = file.Solve(output, currentDir)
-		defaultFileSystem := afero.NewOsFs()
-		conv := pkg.NewConverter(source, output, &defaultFileSystem, "test")
 		err = conv.CreateSQL()
 		return
 	},
 }
 
+// newConverter creates a converter from the source, output and dialect settings.
+func newConverter(cmd *cobra.Command) (conv *pkg.Converter, err error) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get current dir")
+	}
+	source := cmd.Flag("source").Value.String()
+	source = file.Solve(source, currentDir)
+	output := cmd.Flag("output").Value.String()
+	output = file.Solve(output, currentDir)
+	d, err := dialect.New(viper.GetString("dialect"))
+	if err != nil {
+		return
+	}
+	defaultFileSystem := afero.NewOsFs()
+	conv = pkg.NewConverter(d, source, output, &defaultFileSystem, "test")
+	conv.Format, err = migration.NewFormat(viper.GetString("format"))
+	if err != nil {
+		return
+	}
+	conv.Versioning = migration.Versioning{
+		Base:     viper.GetInt64("base-version"),
+		Sequence: viper.GetBool("sequence"),
+	}
+	if snapshotFile := cmd.Flag("snapshot").Value.String(); snapshotFile != "" {
+		conv.SnapshotFile = file.Solve(snapshotFile, currentDir)
+	}
+	if queryDir := cmd.Flag("queries").Value.String(); queryDir != "" {
+		conv.QueryDir = file.Solve(queryDir, currentDir)
+	}
+	if repoDir := cmd.Flag("repository").Value.String(); repoDir != "" {
+		conv.RepoDir = file.Solve(repoDir, currentDir)
+	}
+	conv.Verbose = viper.GetBool("verbose")
+	return
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -71,13 +109,30 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.example-framework.yaml)")
+	rootCmd.PersistentFlags().String("dialect", "mysql", fmt.Sprintf("SQL dialect to generate (%s)", strings.Join(dialect.Names(), ", ")))
+	cobra.CheckErr(viper.BindPFlag("dialect", rootCmd.PersistentFlags().Lookup("dialect")))
+	rootCmd.PersistentFlags().String("format", migration.DefaultFormat, fmt.Sprintf("Migration tool to write the files for (%s)", strings.Join(migration.FormatNames(), ", ")))
+	cobra.CheckErr(viper.BindPFlag("format", rootCmd.PersistentFlags().Lookup("format")))
+	rootCmd.PersistentFlags().Int64("base-version", 0, "Version of the first migration written, for the same file names on every run (default is the current Unix time, or 1 with --sequence)")
+	cobra.CheckErr(viper.BindPFlag("base-version", rootCmd.PersistentFlags().Lookup("base-version")))
+	rootCmd.PersistentFlags().Bool("sequence", false, "Number the migrations 000001, 000002 and so on instead of by Unix time")
+	cobra.CheckErr(viper.BindPFlag("sequence", rootCmd.PersistentFlags().Lookup("sequence")))
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Also report the fields left out on purpose, like unexported fields and fields tagged -")
+	cobra.CheckErr(viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose")))
+	rootCmd.PersistentFlags().String("dsn", "", "Data source name of the database to connect to")
+	cobra.CheckErr(viper.BindPFlag("dsn", rootCmd.PersistentFlags().Lookup("dsn")))
+	rootCmd.PersistentFlags().String("driver", "", "database/sql driver name (default is the dialect name)")
+	cobra.CheckErr(viper.BindPFlag("driver", rootCmd.PersistentFlags().Lookup("driver")))
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 
-	rootCmd.Flags().StringP("source", "s", "", "Directory to search")
-	rootCmd.Flags().StringP("output", "o", "", "Directory to output")
+	rootCmd.PersistentFlags().StringP("source", "s", "", "Directory to search")
+	rootCmd.PersistentFlags().StringP("output", "o", "", "Directory to output")
+	rootCmd.PersistentFlags().String("snapshot", "", "Schema snapshot file (default is <output>/"+snapshot.DefaultFilename+")")
+	rootCmd.PersistentFlags().String("queries", "", "Directory to also write the CRUD statements of each table to as sqlc named queries, like queries")
+	rootCmd.PersistentFlags().String("repository", "", "Directory to also generate a Go repository of each table in, like repository")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -0,0 +1,67 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"text/tabwriter"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which migrations are applied to the database",
+	Long: `List every migration in the output directory together with the
+schema_migrations table of the database given by --dsn. Each migration is
+applied, pending, or missing when it was applied but its file is gone.
+Applied files that were edited afterwards are marked as modified.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		r, db, err := newRunner(cmd)
+		if err != nil {
+			return
+		}
+		defer db.Close()
+		statuses, err := r.Status()
+		if err != nil {
+			return
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT\tCHECKSUM")
+		modified := 0
+		for _, s := range statuses {
+			checksum := ""
+			if s.Modified {
+				checksum = "modified"
+				modified++
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", s.Version, s.Name, s.Status, s.AppliedAt, checksum)
+		}
+		if err = w.Flush(); err != nil {
+			return
+		}
+		if modified > 0 {
+			err = fmt.Errorf("auto-table: %d applied migrations were modified afterwards", modified)
+		}
+		return
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
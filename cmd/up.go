@@ -0,0 +1,53 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"strconv"
+)
+
+// upCmd represents the up command
+var upCmd = &cobra.Command{
+	Use:     "up [N]",
+	Aliases: []string{"apply"},
+	Short:   "Apply the next N pending migrations to the database",
+	Long: `Run the next N migrations in the output directory that are not recorded in
+the schema_migrations table, oldest first. All pending migrations are run
+if N is omitted.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		n := 0
+		if len(args) == 1 {
+			n, err = strconv.Atoi(args[0])
+			if err != nil {
+				return
+			}
+		}
+		r, db, err := newRunner(cmd)
+		if err != nil {
+			return
+		}
+		defer db.Close()
+		applied, err := r.Up(n)
+		printMigrates(cmd, "applied", applied)
+		return
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(upCmd)
+}
@@ -6,12 +6,148 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/packages"
+	"path/filepath"
+	"strings"
 )
 
 type StructAST struct {
 	Name       string
+	Filename   string
+	Package    string
 	StructType *ast.StructType
 	Annotation *annotation
+	Fset       *token.FileSet // File set the struct was parsed with, to find the positions of its fields
+	PkgPath    string         // Import path of the package, empty if the file was parsed without type checking
+	Info       *types.Info    // Types of the package the struct is declared in, nil if the file was parsed without type checking
+
+	packages map[string]*packages.Package // map[importPath]package of the loaded packages and their dependencies, to find the structs embedded by fields
+}
+
+// Position returns the position of a node of the struct in its file.
+func (s *StructAST) Position(n ast.Node) token.Position {
+	if s.Fset == nil {
+		return token.Position{Filename: s.Filename}
+	}
+	return s.Fset.Position(n.Pos())
+}
+
+// SelfReference returns the fields of the struct referencing the struct itself, a pointer to its parent like Parent *Category
+// and a slice of its children like Children []Category with its type. The names are empty if there are no such fields.
+func (s *StructAST) SelfReference() (parent string, children string, childrenType string) {
+	for _, f := range s.StructType.Fields.List {
+		if len(f.Names) == 0 {
+			continue
+		}
+		str, name, isPtr, isArray, err := DetectTypeName(f.Type)
+		if err != nil {
+			continue
+		}
+		switch {
+		case isPtr && name == s.Name && parent == "":
+			parent = f.Names[0].Name
+		case isArray && strings.TrimPrefix(name, "*") == s.Name && children == "":
+			children, childrenType = f.Names[0].Name, str
+		}
+	}
+	return
+}
+
+// Embedded returns the struct a field embeds, like Timestamps of type Post struct { Timestamps }, with the types of the package it is declared in.
+// Without type checking, only structs declared in the files of the same directory can be found.
+func (s *StructAST) Embedded(f *ast.Field) (*StructAST, error) {
+	if s.Info == nil {
+		return s.embeddedInDir(f)
+	}
+	t := s.Info.TypeOf(f.Type)
+	if t == nil || !resolved(t) {
+		return s.embeddedInDir(f)
+	}
+	if p, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil, fmt.Errorf("auto-table: the embedded %s is not a struct", types.ExprString(f.Type))
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil, fmt.Errorf("auto-table: the embedded %s is not a struct", types.ExprString(f.Type))
+	}
+	obj := named.Obj()
+	pkg, ok := s.packages[obj.Pkg().Path()]
+	if !ok {
+		return nil, fmt.Errorf("auto-table: the package of the embedded %s is not loaded", types.ExprString(f.Type))
+	}
+	for _, file := range pkg.Syntax {
+		if spec := findStruct(file, obj.Name()); spec != nil && spec.Name.Pos() == obj.Pos() {
+			return &StructAST{
+				Name:       obj.Name(),
+				Filename:   pkg.Fset.Position(spec.Pos()).Filename,
+				Package:    pkg.Name,
+				StructType: spec.Type.(*ast.StructType),
+				Fset:       pkg.Fset,
+				PkgPath:    pkg.PkgPath,
+				Info:       pkg.TypesInfo,
+				packages:   s.packages,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("auto-table: the declaration of the embedded %s is not found", types.ExprString(f.Type))
+}
+
+// embeddedInDir finds the struct a field embeds in the files of the directory of the struct, parsing them one by one.
+func (s *StructAST) embeddedInDir(f *ast.Field) (*StructAST, error) {
+	_, name, _, _, err := DetectTypeName(f.Type)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimPrefix(name, "*")
+	if strings.Contains(name, ".") {
+		return nil, fmt.Errorf("auto-table: the embedded %s cannot be found without loading its package", name)
+	}
+	filenames, err := filepath.Glob(filepath.Join(filepath.Dir(s.Filename), "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	for _, filename := range filenames {
+		if strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		if err != nil || file.Name.Name != s.Package {
+			continue
+		}
+		if spec := findStruct(file, name); spec != nil {
+			return &StructAST{
+				Name:       name,
+				Filename:   filename,
+				Package:    file.Name.Name,
+				StructType: spec.Type.(*ast.StructType),
+				Fset:       fset,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("auto-table: the embedded struct %s is not found", name)
+}
+
+// findStruct returns the declaration of the struct of the name in the file, or nil if there is none.
+func findStruct(file *ast.File, name string) *ast.TypeSpec {
+	for _, decl := range file.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || d.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range d.Specs {
+			if s, ok := spec.(*ast.TypeSpec); ok && s.Name.Name == name {
+				if _, ok := s.Type.(*ast.StructType); ok {
+					return s
+				}
+			}
+		}
+	}
+	return nil
 }
 
 func MakeStructASTMap(filename string, marker string) (map[string]*StructAST, error) {
@@ -20,6 +156,11 @@ func MakeStructASTMap(filename string, marker string) (map[string]*StructAST, er
 	if err != nil {
 		return nil, err
 	}
+	return makeStructASTMap(fset, f, filename, marker)
+}
+
+// makeStructASTMap collects the structs of a parsed file marked by //+marker, keyed by their table names.
+func makeStructASTMap(fset *token.FileSet, f *ast.File, filename string, marker string) (map[string]*StructAST, error) {
 	structASTMap := map[string]*StructAST{}
 	for _, decl := range f.Decls {
 		d, ok := decl.(*ast.GenDecl)
@@ -47,8 +188,11 @@ func MakeStructASTMap(filename string, marker string) (map[string]*StructAST, er
 			}
 			st := &StructAST{
 				Name:       s.Name.Name,
+				Filename:   filename,
+				Package:    f.Name.Name,
 				StructType: t,
 				Annotation: annotation,
+				Fset:       fset,
 			}
 			if annotation.Table != "" {
 				structASTMap[annotation.Table] = st
@@ -102,3 +246,84 @@ func DetectTypeName(n ast.Node) (str string, name string, isPtr bool, isArray bo
 		return
 	}
 }
+
+// DetectType reads the type of a field like DetectTypeName, resolving it with the types of the package if the struct was type checked.
+// Named types of basic types, like type UserID string, are resolved to their basic types, and aliases to the types they stand for.
+// Other named types keep their names, qualified by their package names if they are declared in another package, and pkgPath is the import path
+// of the package, empty if it is unknown. Types the type checker could not resolve, like types of files outside of the package, are read like DetectTypeName does.
+func (s *StructAST) DetectType(expr ast.Expr) (str string, name string, pkgPath string, isPtr bool, isArray bool, err error) {
+	var t types.Type
+	if s.Info != nil {
+		t = s.Info.TypeOf(expr)
+	}
+	if t != nil {
+		t = types.Unalias(t)
+	}
+	if t == nil || !resolved(t) {
+		str, name, isPtr, isArray, err = DetectTypeName(expr)
+		return
+	}
+	switch elem := t.(type) {
+	case *types.Pointer:
+		name, pkgPath, err = s.typeString(elem.Elem())
+		str, isPtr = "*"+name, true
+	case *types.Slice:
+		name, pkgPath, err = s.typeString(elem.Elem())
+		str, isArray = "[]"+name, true
+	case *types.Array:
+		name, pkgPath, err = s.typeString(elem.Elem())
+		str, isArray = "[]"+name, true
+	default:
+		str, pkgPath, err = s.typeString(t)
+		name = str
+	}
+	return
+}
+
+// resolved reports whether the type checker resolved the type and the types of its elements.
+func resolved(t types.Type) bool {
+	switch t := types.Unalias(t).(type) {
+	case *types.Basic:
+		return t.Kind() != types.Invalid
+	case *types.Pointer:
+		return resolved(t.Elem())
+	case *types.Slice:
+		return resolved(t.Elem())
+	case *types.Array:
+		return resolved(t.Elem())
+	}
+	return true
+}
+
+// typeString names a type the way the dialects know it, like int64, time.Time or *User.
+func (s *StructAST) typeString(t types.Type) (str string, pkgPath string, err error) {
+	switch t := types.Unalias(t).(type) {
+	case *types.Basic:
+		str = t.Name()
+	case *types.Pointer:
+		str, pkgPath, err = s.typeString(t.Elem())
+		str = "*" + str
+	case *types.Slice:
+		str, pkgPath, err = s.typeString(t.Elem())
+		str = "[]" + str
+	case *types.Array:
+		str, pkgPath, err = s.typeString(t.Elem())
+		str = "[]" + str
+	case *types.Named:
+		if b, ok := t.Underlying().(*types.Basic); ok {
+			str = b.Name()
+			return
+		}
+		obj := t.Obj()
+		str = obj.Name()
+		if obj.Pkg() != nil {
+			pkgPath = obj.Pkg().Path()
+			if pkgPath != s.PkgPath {
+				str = obj.Pkg().Name() + "." + obj.Name()
+			}
+		}
+	default:
+		err = fmt.Errorf("auto-table: %s cannot be a column", t)
+	}
+	return
+}
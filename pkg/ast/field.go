@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"fmt"
 	"github.com/hourglasshoro/auto-table/pkg/dialect"
 	"github.com/naoina/go-stringutil"
 	"go/ast"
@@ -25,6 +26,8 @@ type Field struct {
 	Extra         string
 	Nullable      bool
 	ForeignKey    *ForeignKey
+	StructField   string // Name of the struct field the column is made from, differs from Name for relations
+	StructType    string // Type of the struct field as declared, differs from GoType for relations
 }
 
 type ForeignKey struct {
@@ -49,10 +52,12 @@ func NewField(
 	}
 	if len(f.Names) > 0 && f.Names[0] != nil {
 		ret.Name = f.Names[0].Name
+		ret.StructField = f.Names[0].Name
 		if fieldName != nil {
 			ret.Name = *fieldName
 		}
 	}
+	ret.StructType, _, _, _, _ = DetectTypeName(f.Type)
 	ret.PrimaryKey = primaryKey
 	ret.AutoIncrement = autoIncrement
 	if ret.IsEmbedded() {
@@ -97,6 +102,12 @@ func (f *Field) IsEmbedded() bool {
 	return f.Name == ""
 }
 
+// IsStructField reports whether the column is read from and written to the struct field of the same name and type.
+// Columns made from relations, like the ID of a referenced struct, are not.
+func (f *Field) IsStructField() bool {
+	return f.Name == f.StructField && f.GoType == f.StructType
+}
+
 func (f *Field) ToField() dialect.Field {
 	return dialect.Field{
 		Table:         f.Table,
@@ -123,7 +134,12 @@ func MakeForeignKeyColumns(fields []*Field) (fks map[string]dialect.ForeignKey)
 	fks = map[string]dialect.ForeignKey{} //map[fieldName]reference
 	for _, f := range fields {
 		if f.ForeignKey != nil {
-			fks[f.Name] = dialect.ForeignKey{
+			// Keyed by the field name, or by the column if it is not named after the field, like the prefixed columns of embedded structs.
+			name := f.Name
+			if f.Column != stringutil.ToSnakeCase(f.Name) {
+				name = f.Column
+			}
+			fks[name] = dialect.ForeignKey{
 				Table:  f.ForeignKey.Table,
 				Column: f.ForeignKey.Column,
 			}
@@ -132,3 +148,38 @@ func MakeForeignKeyColumns(fields []*Field) (fks map[string]dialect.ForeignKey)
 
 	return
 }
+
+// MakeIndexes groups the fields that share an index name into composite indexes in the order they first appear.
+// Fields whose index tag has no name get a single column index named after the table and the column.
+func MakeIndexes(tableName string, fields []*Field) (indexes []dialect.Index) {
+	indexMap := map[string]int{} // map[indexName]position in indexes
+	add := func(name string, unique bool, f *Field) {
+		if name == "" {
+			prefix := "idx"
+			if unique {
+				prefix = "uq"
+			}
+			name = fmt.Sprintf("%s_%s_%s", prefix, tableName, f.Column)
+		}
+		if i, ok := indexMap[name]; ok {
+			indexes[i].Columns = append(indexes[i].Columns, f.Column)
+			return
+		}
+		indexMap[name] = len(indexes)
+		indexes = append(indexes, dialect.Index{
+			Table:   tableName,
+			Name:    name,
+			Columns: []string{f.Column},
+			Unique:  unique,
+		})
+	}
+	for _, f := range fields {
+		for _, name := range f.RawIndexes {
+			add(name, false, f)
+		}
+		for _, name := range f.RawUniques {
+			add(name, true, f)
+		}
+	}
+	return
+}
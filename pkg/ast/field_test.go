@@ -0,0 +1,51 @@
+package ast
+
+import (
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"reflect"
+	"testing"
+)
+
+func TestMakeIndexes(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []*Field
+		want   []dialect.Index
+	}{
+		{
+			name: "unnamed",
+			fields: []*Field{
+				{Column: "name", RawIndexes: []string{""}},
+				{Column: "email", RawUniques: []string{""}},
+			},
+			want: []dialect.Index{
+				{Table: "user", Name: "idx_user_name", Columns: []string{"name"}},
+				{Table: "user", Name: "uq_user_email", Columns: []string{"email"}, Unique: true},
+			},
+		},
+		{
+			name: "composite",
+			fields: []*Field{
+				{Column: "first_name", RawIndexes: []string{"user_full_name_idx"}},
+				{Column: "email", RawUniques: []string{"user_email_uq"}},
+				{Column: "last_name", RawIndexes: []string{"user_full_name_idx"}},
+			},
+			want: []dialect.Index{
+				{Table: "user", Name: "user_full_name_idx", Columns: []string{"first_name", "last_name"}},
+				{Table: "user", Name: "user_email_uq", Columns: []string{"email"}, Unique: true},
+			},
+		},
+		{
+			name:   "none",
+			fields: []*Field{{Column: "id"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MakeIndexes("user", tt.fields)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MakeIndexes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package ast
+
+import (
+	"errors"
+	"github.com/hourglasshoro/auto-table/pkg/diagnostic"
+	"go/token"
+	"golang.org/x/tools/go/packages"
+	"path/filepath"
+)
+
+// Dependencies are type checked from source too, as the export data the go command writes may be newer than go/packages can read.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedTypesSizes | packages.NeedImports | packages.NeedDeps
+
+// LoadStructASTMap collects the structs marked by //+marker of the files like MakeStructASTMap does, type checking the packages of the files
+// so that DetectType can resolve the types of their fields.
+// Files that cannot be loaded as a package, like files outside of a module, are parsed one by one without type checking,
+// and a warning with the reason is returned for each of them.
+func LoadStructASTMap(filenames []string, marker string) (map[string]*StructAST, diagnostic.List, error) {
+	structASTMap := map[string]*StructAST{}
+	var diags diagnostic.List
+	var dirs []string
+	dirFiles := map[string][]string{} // map[dir]filenames
+	for _, filename := range filenames {
+		dir := filepath.Dir(filename)
+		if _, ok := dirFiles[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		dirFiles[dir] = append(dirFiles[dir], filename)
+	}
+
+	for _, dir := range dirs {
+		m, rest, reason, err := loadDir(dir, dirFiles[dir], marker)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, filename := range rest {
+			diags.Report(token.Position{Filename: filename, Line: 1, Column: 1}, diagnostic.Warnf("types of the fields are not resolved: %v", reason))
+			fm, err := MakeStructASTMap(filename, marker)
+			if err != nil {
+				return nil, nil, err
+			}
+			for k, v := range fm {
+				m[k] = v
+			}
+		}
+		for k, v := range m {
+			structASTMap[k] = v
+		}
+	}
+	return structASTMap, diags, nil
+}
+
+// loadDir loads the package of the files in a directory, and returns the structs of the files found in the package and the files that were not,
+// with the reason they were not.
+// Type errors are left to the generation, which reports the fields it cannot make columns of.
+func loadDir(dir string, filenames []string, marker string) (structASTMap map[string]*StructAST, rest []string, reason error, err error) {
+	structASTMap = map[string]*StructAST{}
+	rest = filenames
+	absFiles := map[string]string{} // map[absolutePath]filename
+	var query string
+	for _, filename := range filenames {
+		abs, aErr := filepath.Abs(filename)
+		if aErr != nil {
+			reason = aErr
+			return
+		}
+		absFiles[abs] = filename
+		if query == "" {
+			query = "file=" + abs
+		}
+	}
+
+	fset := token.NewFileSet()
+	cfg := &packages.Config{Mode: loadMode, Dir: dir, Fset: fset}
+	pkgs, lErr := packages.Load(cfg, query)
+	if lErr != nil {
+		reason = lErr
+		return
+	}
+	for _, pkg := range pkgs {
+		if pkg.PkgPath == "command-line-arguments" {
+			// The go command makes a package of the queried file alone when it is outside of a module.
+			reason = errors.New("the directory is not in a Go module")
+			return
+		}
+		for _, e := range pkg.Errors {
+			if e.Kind != packages.TypeError {
+				// Syntax errors are reported by parsing the files one by one.
+				reason = e
+				return
+			}
+		}
+	}
+
+	loaded := map[string]*packages.Package{} // map[importPath]package
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		loaded[pkg.PkgPath] = pkg
+	})
+
+	found := map[string]bool{}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Syntax {
+			filename, ok := absFiles[fset.Position(f.Package).Filename]
+			if !ok || found[filename] {
+				continue
+			}
+			m, mErr := makeStructASTMap(fset, f, filename, marker)
+			if mErr != nil {
+				err = mErr
+				return
+			}
+			for k, v := range m {
+				v.PkgPath = pkg.PkgPath
+				v.Info = pkg.TypesInfo
+				v.packages = loaded
+				structASTMap[k] = v
+			}
+			found[filename] = true
+		}
+	}
+	rest = nil
+	for _, filename := range filenames {
+		if !found[filename] {
+			rest = append(rest, filename)
+		}
+	}
+	if len(rest) > 0 {
+		reason = errors.New("the file is not in the package loaded")
+	}
+	return
+}
@@ -0,0 +1,68 @@
+package ast
+
+import (
+	"github.com/hourglasshoro/auto-table/pkg/diagnostic"
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadStructASTMap(t *testing.T) {
+	filename := filepath.Join("testdata", "typed", "models", "user.go")
+	structASTMap, diags, err := LoadStructASTMap([]string{filename}, "+test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 0 {
+		t.Errorf("LoadStructASTMap() diagnostics = %v, want none", diags)
+	}
+	s, ok := structASTMap["user"]
+	if !ok {
+		t.Fatalf("LoadStructASTMap() = %v, want the user struct", structASTMap)
+	}
+	if s.PkgPath != "example.com/typed/models" {
+		t.Errorf("PkgPath = %q, want example.com/typed/models", s.PkgPath)
+	}
+
+	mysql := dialect.NewMySQL()
+	var got []string
+	for _, f := range s.StructType.Fields.List {
+		str, _, _, _, _, err := s.DetectType(f.Type)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, f.Names[0].Name+" "+str+" "+mysql.ColumnType(str))
+	}
+	want := []string{
+		"ID string VARCHAR(255)",
+		"Score int INT",
+		"Email string VARCHAR(255)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DetectType() = %q, want %q", got, want)
+	}
+}
+
+func TestLoadStructASTMapOutsideModule(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "user.go")
+	src := "package models\n\ntype UserID string\n\n//+test\ntype User struct {\n\tID UserID\n}\n"
+	if err := os.WriteFile(filename, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	structASTMap, diags, err := LoadStructASTMap([]string{filename}, "+test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, ok := structASTMap["user"]
+	if !ok {
+		t.Fatalf("LoadStructASTMap() = %v, want the user struct", structASTMap)
+	}
+	if str, _, _, _, _, _ := s.DetectType(s.StructType.Fields.List[0].Type); str != "UserID" {
+		t.Errorf("DetectType() = %q, want the name UserID as written", str)
+	}
+	if len(diags) != 1 || diags[0].Severity != diagnostic.Warning || diags[0].Pos.Filename != filename {
+		t.Errorf("LoadStructASTMap() diagnostics = %v, want a warning of %s", diags, filename)
+	}
+}
@@ -1,6 +1,16 @@
 package ast
 
+import "go/token"
+
 type Table struct {
-	Fields []*Field
-	Option string
+	Fields   []*Field
+	Option   string
+	Struct   string         // Name of the struct the table is made from, empty for cross reference tables
+	Filename string         // File the struct, or the struct owning the cross reference, is declared in
+	Pos      token.Position // Position of the struct, or of the struct owning the cross reference
+	Package  string         // Package the struct is declared in
+
+	// Slice field of the struct holding the records whose foreign key references the table itself, like Children []Category
+	Children     string
+	ChildrenType string
 }
@@ -3,7 +3,10 @@ package ast
 import (
 	"bufio"
 	"fmt"
+	"github.com/naoina/go-stringutil"
+	"go/ast"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -18,6 +21,7 @@ const (
 	tagType          = "type"
 	tagNull          = "null"
 	tagExtra         = "extra"
+	tagPrefix        = "prefix"
 	tagIgnore        = "-"
 )
 
@@ -77,6 +81,8 @@ func parseStructTag(marker string, f *Field, tag reflect.StructTag) error {
 				return fmt.Errorf("`extra` tag must specify the parameter")
 			}
 			f.Extra = optval[1]
+		case tagPrefix:
+			return fmt.Errorf("`prefix` tag is only for embedded structs: %s", f.Name)
 		default:
 			return fmt.Errorf("unknown option: `%s'", opt)
 		}
@@ -84,6 +90,50 @@ func parseStructTag(marker string, f *Field, tag reflect.StructTag) error {
 	return scanner.Err()
 }
 
+// ParseEmbeddedTag reads the tag of a field embedding a struct, whose fields are flattened into columns.
+// The columns are prefixed by the prefix option like prefix:address_, or by the snake cased struct name if the option has no parameter,
+// and the struct is left out by the - option.
+func ParseEmbeddedTag(marker string, f *ast.Field) (prefix string, ignore bool, err error) {
+	if f.Tag == nil {
+		return
+	}
+	s, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return
+	}
+	tagStr := reflect.StructTag(s).Get(marker)
+	if tagStr == "" {
+		return
+	}
+	scanner := bufio.NewScanner(strings.NewReader(tagStr))
+	scanner.Split(tagOptionSplit)
+	for scanner.Scan() {
+		opt := scanner.Text()
+		optval := strings.SplitN(opt, ":", 2)
+		switch optval[0] {
+		case tagPrefix:
+			if len(optval) == 2 {
+				prefix = optval[1]
+			} else {
+				_, name, _, _, tErr := DetectTypeName(f.Type)
+				if tErr != nil {
+					err = tErr
+					return
+				}
+				name = strings.TrimPrefix(name, "*")
+				prefix = stringutil.ToSnakeCase(name[strings.LastIndex(name, ".")+1:]) + "_"
+			}
+		case tagIgnore:
+			ignore = true
+		default:
+			err = fmt.Errorf("embedded structs only take the `prefix` and `-` options: `%s'", opt)
+			return
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
 func tagOptionSplit(data []byte, atEOF bool) (advance int, token []byte, err error) {
 	var inParenthesis bool
 	for i := 0; i < len(data); i++ {
@@ -0,0 +1,14 @@
+package models
+
+import "example.com/typed/shared"
+
+type UserID string
+
+type Score = int
+
+// +test
+type User struct {
+	ID    UserID
+	Score Score
+	Email shared.Email
+}
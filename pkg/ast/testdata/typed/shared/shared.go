@@ -0,0 +1,3 @@
+package shared
+
+type Email string
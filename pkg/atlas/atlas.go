@@ -0,0 +1,228 @@
+package atlas
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/diff"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// simpleType matches the types Atlas accepts as they are, like varchar(255). Other types are written with sql().
+	simpleType = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\([0-9, ]+\))?$`)
+	number     = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+)
+
+// serialTypes are the types PostgreSQL auto increment columns are created with, like the postgres dialect does.
+var serialTypes = map[string]string{
+	"SMALLINT": "smallserial",
+	"INTEGER":  "serial",
+	"BIGINT":   "bigserial",
+}
+
+// Export writes the tables as an Atlas HCL schema of the dialect, so that Atlas can plan migrations from the structs.
+// The created_at and updated_at columns the dialects add to every table are included.
+// Foreign keys are named <table>_<column>_fkey, which is the name PostgreSQL gives them.
+func Export(dialectName string, tables []dialect.Table, schema string) ([]byte, error) {
+	switch dialectName {
+	case "mysql", "postgres", "sqlite":
+	default:
+		return nil, fmt.Errorf("auto-table: the %s dialect cannot be exported to Atlas", dialectName)
+	}
+	tables = append([]dialect.Table(nil), tables...)
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "schema %s {\n}\n", strconv.Quote(schema))
+	for _, t := range tables {
+		b.WriteString("\n")
+		writeTable(&b, dialectName, t, schema)
+	}
+	return b.Bytes(), nil
+}
+
+func writeTable(b *bytes.Buffer, dialectName string, t dialect.Table, schema string) {
+	fmt.Fprintf(b, "table %s {\n", strconv.Quote(t.Name))
+	fmt.Fprintf(b, "  schema = %s\n", reference("schema", schema))
+	for _, f := range append(t.Fields, timestampFields(dialectName, t.Name)...) {
+		writeColumn(b, dialectName, f)
+	}
+	if len(t.PrimaryKeys) > 0 {
+		fmt.Fprintf(b, "  primary_key {\n    columns = %s\n  }\n", columns(t.PrimaryKeys))
+	}
+
+	fks := diff.NormalizeForeignKeys(t.ForeignKeys)
+	fkColumns := make([]string, 0, len(fks))
+	for column := range fks {
+		fkColumns = append(fkColumns, column)
+	}
+	sort.Strings(fkColumns)
+	for _, column := range fkColumns {
+		ref := fks[column]
+		fmt.Fprintf(b, "  foreign_key %s {\n", strconv.Quote(dialect.ForeignKeyName(t.Name, column)))
+		fmt.Fprintf(b, "    columns     = %s\n", columns([]string{column}))
+		fmt.Fprintf(b, "    ref_columns = [%s.%s]\n", reference("table", ref.Table), reference("column", ref.Column))
+		b.WriteString("  }\n")
+	}
+
+	for _, idx := range t.Indexes {
+		fmt.Fprintf(b, "  index %s {\n", strconv.Quote(idx.Name))
+		if idx.Unique {
+			b.WriteString("    unique  = true\n")
+		}
+		fmt.Fprintf(b, "    columns = %s\n", columns(idx.Columns))
+		b.WriteString("  }\n")
+	}
+	writeOptions(b, dialectName, t.Option)
+	b.WriteString("}\n")
+}
+
+func writeColumn(b *bytes.Buffer, dialectName string, f dialect.Field) {
+	var attrs [][2]string
+	attrs = append(attrs, [2]string{"null", strconv.FormatBool(f.Nullable)})
+	typ := columnType(f.Type)
+	if f.AutoIncrement && dialectName == "postgres" {
+		if serial, ok := serialTypes[strings.ToUpper(f.Type)]; ok {
+			typ = serial
+		}
+	}
+	attrs = append(attrs, [2]string{"type", typ})
+	if f.Default != "" {
+		attrs = append(attrs, [2]string{"default", defaultValue(f)})
+	}
+	if f.AutoIncrement && dialectName != "postgres" {
+		attrs = append(attrs, [2]string{"auto_increment", "true"})
+	}
+	if strings.HasPrefix(strings.ToUpper(f.Extra), "ON UPDATE ") && dialectName == "mysql" {
+		attrs = append(attrs, [2]string{"on_update", fmt.Sprintf("sql(%s)", hclString(strings.TrimSpace(f.Extra[len("ON UPDATE "):])))})
+	}
+	if f.Comment != "" && dialectName != "sqlite" {
+		attrs = append(attrs, [2]string{"comment", hclString(f.Comment)})
+	}
+
+	width := 0
+	for _, attr := range attrs {
+		if len(attr[0]) > width {
+			width = len(attr[0])
+		}
+	}
+	fmt.Fprintf(b, "  column %s {\n", strconv.Quote(f.Name))
+	for _, attr := range attrs {
+		fmt.Fprintf(b, "    %-*s = %s\n", width, attr[0], attr[1])
+	}
+	if f.AutoIncrement && dialectName == "postgres" && typ == columnType(f.Type) {
+		b.WriteString("    identity {\n      generated = BY_DEFAULT\n    }\n")
+	}
+	b.WriteString("  }\n")
+}
+
+// writeOptions writes the MySQL table options Atlas has attributes for. Other options cannot be exported and are left as comments.
+func writeOptions(b *bytes.Buffer, dialectName string, option string) {
+	if option == "" {
+		return
+	}
+	var rest []string
+	for _, opt := range splitOption(option) {
+		kv := strings.SplitN(opt, "=", 2)
+		key := strings.ToUpper(strings.TrimSpace(kv[0]))
+		key = strings.TrimPrefix(key, "DEFAULT ")
+		if len(kv) != 2 || dialectName != "mysql" {
+			rest = append(rest, opt)
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(kv[1]), "'\"")
+		switch key {
+		case "ENGINE":
+			fmt.Fprintf(b, "  engine  = %s\n", value)
+		case "CHARSET", "CHARACTER SET":
+			fmt.Fprintf(b, "  charset = %s\n", hclString(value))
+		case "COLLATE":
+			fmt.Fprintf(b, "  collate = %s\n", hclString(value))
+		case "COMMENT":
+			fmt.Fprintf(b, "  comment = %s\n", hclString(value))
+		default:
+			rest = append(rest, opt)
+		}
+	}
+	for _, opt := range rest {
+		fmt.Fprintf(b, "  # auto-table: the table option %s is not exported\n", opt)
+	}
+}
+
+// splitOption splits table options like ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 into single options.
+func splitOption(option string) (options []string) {
+	words := strings.Fields(strings.ReplaceAll(option, " = ", "="))
+	for i := 0; i < len(words); i++ {
+		opt := words[i]
+		for !strings.Contains(opt, "=") && i+1 < len(words) {
+			i++
+			opt += " " + words[i]
+		}
+		options = append(options, opt)
+	}
+	return
+}
+
+// timestampFields returns the created_at and updated_at columns as the dialect creates them.
+func timestampFields(dialectName string, table string) []dialect.Field {
+	typ, extra := "TIMESTAMP", ""
+	switch dialectName {
+	case "mysql":
+		extra = "ON UPDATE CURRENT_TIMESTAMP"
+	case "sqlite":
+		typ = "DATETIME"
+	}
+	return []dialect.Field{
+		{Table: table, Name: "created_at", Type: typ, Default: "CURRENT_TIMESTAMP", Nullable: true},
+		{Table: table, Name: "updated_at", Type: typ, Default: "CURRENT_TIMESTAMP", Extra: extra, Nullable: true},
+	}
+}
+
+func columnType(typ string) string {
+	if lower := strings.ToLower(typ); simpleType.MatchString(lower) {
+		return lower
+	}
+	return fmt.Sprintf("sql(%s)", hclString(typ))
+}
+
+// defaultValue writes a default as a string for text and enum columns, and as a number, a bool or an SQL expression otherwise.
+func defaultValue(f dialect.Field) string {
+	typ := strings.ToUpper(f.Type)
+	switch {
+	case strings.Contains(typ, "CHAR") || strings.Contains(typ, "TEXT") || strings.HasPrefix(typ, "ENUM(") || strings.HasPrefix(typ, "SET("):
+		return hclString(f.Default)
+	case number.MatchString(f.Default):
+		return f.Default
+	case strings.EqualFold(f.Default, "true") || strings.EqualFold(f.Default, "false"):
+		return strings.ToLower(f.Default)
+	}
+	return fmt.Sprintf("sql(%s)", hclString(f.Default))
+}
+
+func columns(names []string) string {
+	refs := make([]string, len(names))
+	for i, name := range names {
+		refs[i] = reference("column", name)
+	}
+	return "[" + strings.Join(refs, ", ") + "]"
+}
+
+// reference refers to a block by its name, like column.id, or column["order id"] if the name is not an identifier.
+func reference(kind string, name string) string {
+	if identifier.MatchString(name) {
+		return kind + "." + name
+	}
+	return fmt.Sprintf("%s[%s]", kind, hclString(name))
+}
+
+// hclString quotes a string, escaping the sequences HCL would read as templates.
+func hclString(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	s = strings.ReplaceAll(s, "%{", "%%{")
+	return strconv.Quote(s)
+}
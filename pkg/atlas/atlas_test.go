@@ -0,0 +1,70 @@
+package atlas
+
+import (
+	"flag"
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+var tables = []dialect.Table{
+	{
+		Name: "user",
+		Fields: []dialect.Field{
+			{Table: "user", Name: "id", Type: "BIGINT", AutoIncrement: true},
+			{Table: "user", Name: "name", Type: "VARCHAR(255)", Default: "${guest}", Comment: "login name"},
+			{Table: "user", Name: "bio", Type: "TEXT", Nullable: true},
+			{Table: "user", Name: "score", Type: "DECIMAL(10, 2)", Default: "0.5"},
+			{Table: "user", Name: "role", Type: "enum('admin','member')", Default: "member"},
+		},
+		PrimaryKeys: []string{"id"},
+		Indexes:     []dialect.Index{{Table: "user", Name: "user_name_idx", Columns: []string{"name"}, Unique: true}},
+		Option:      "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ROW_FORMAT=DYNAMIC",
+	},
+	{
+		Name: "micropost",
+		Fields: []dialect.Field{
+			{Table: "micropost", Name: "id", Type: "UUID"},
+			{Table: "micropost", Name: "author_id", Type: "BIGINT"},
+			{Table: "micropost", Name: "order no", Type: "INT", Nullable: true},
+			{Table: "micropost", Name: "published_at", Type: "DATETIME", Default: "CURRENT_TIMESTAMP", Extra: "ON UPDATE CURRENT_TIMESTAMP"},
+		},
+		PrimaryKeys: []string{"id"},
+		ForeignKeys: map[string]dialect.ForeignKey{"AuthorID": {Table: "User", Column: "ID"}},
+		Indexes:     []dialect.Index{{Table: "micropost", Name: "micropost_author_order_idx", Columns: []string{"author_id", "order no"}}},
+	},
+}
+
+func TestExport(t *testing.T) {
+	for _, name := range []string{"mysql", "postgres", "sqlite"} {
+		t.Run(name, func(t *testing.T) {
+			got, err := Export(name, tables, "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", name+".hcl")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Export() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+
+	if _, err := Export("oracle", tables, "app"); err == nil {
+		t.Error("Export() of an unknown dialect succeeded")
+	}
+}
@@ -0,0 +1,91 @@
+schema "app" {
+}
+
+table "micropost" {
+  schema = schema.app
+  column "id" {
+    null = false
+    type = uuid
+  }
+  column "author_id" {
+    null = false
+    type = bigint
+  }
+  column "order no" {
+    null = true
+    type = int
+  }
+  column "published_at" {
+    null    = false
+    type    = datetime
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  column "created_at" {
+    null    = true
+    type    = datetime
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  column "updated_at" {
+    null    = true
+    type    = datetime
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "micropost_author_id_fkey" {
+    columns     = [column.author_id]
+    ref_columns = [table.user.column.id]
+  }
+  index "micropost_author_order_idx" {
+    columns = [column.author_id, column["order no"]]
+  }
+}
+
+table "user" {
+  schema = schema.app
+  column "id" {
+    null           = false
+    type           = bigint
+    auto_increment = true
+  }
+  column "name" {
+    null    = false
+    type    = varchar(255)
+    default = "$${guest}"
+  }
+  column "bio" {
+    null = true
+    type = text
+  }
+  column "score" {
+    null    = false
+    type    = decimal(10, 2)
+    default = 0.5
+  }
+  column "role" {
+    null    = false
+    type    = sql("enum('admin','member')")
+    default = "member"
+  }
+  column "created_at" {
+    null    = true
+    type    = datetime
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  column "updated_at" {
+    null    = true
+    type    = datetime
+    default = sql("CURRENT_TIMESTAMP")
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  index "user_name_idx" {
+    unique  = true
+    columns = [column.name]
+  }
+  # auto-table: the table option ENGINE=InnoDB is not exported
+  # auto-table: the table option DEFAULT CHARSET=utf8mb4 is not exported
+  # auto-table: the table option ROW_FORMAT=DYNAMIC is not exported
+}
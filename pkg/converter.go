@@ -2,44 +2,195 @@ package pkg
 
 import (
 	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/diagnostic"
 	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/diff"
 	"github.com/hourglasshoro/auto-table/pkg/file"
 	"github.com/hourglasshoro/auto-table/pkg/migration"
+	"github.com/hourglasshoro/auto-table/pkg/query"
+	"github.com/hourglasshoro/auto-table/pkg/repository"
+	"github.com/hourglasshoro/auto-table/pkg/snapshot"
 	"github.com/hourglasshoro/auto-table/pkg/sql"
 	"github.com/spf13/afero"
+	"go/token"
+	"log"
+	"path/filepath"
 )
 
 type Converter struct {
-	Dialect    dialect.Dialect
-	AutoID     bool // Flag to automatically set id as primary key
-	SourceDir  string
-	OutputDir  string
-	FileSystem *afero.Fs
-	Marker     string
-	TagMaker   string
+	Dialect      dialect.Dialect
+	AutoID       bool // Flag to automatically set id as primary key
+	SourceDir    string
+	OutputDir    string
+	Format       migration.Format     // Layout of the migration files, golang-migrate if nil
+	Versioning   migration.Versioning // Versions of the migration files
+	SnapshotFile string               // Schema snapshot written on every generation and used as the baseline of DiffSQL
+	QueryDir     string               // Directory the CRUD statements are written to as named queries, nothing is written if empty
+	RepoDir      string               // Directory a Go repository of each table is generated in, nothing is generated if empty
+	Verbose      bool                 // Log the fields left out on purpose too, like unexported fields
+	FileSystem   *afero.Fs
+	Marker       string
+	TagMaker     string
 }
 
+// NewConverter creates a converter generating the migrations of the dialect, like one returned by dialect.New.
 func NewConverter(
+	d dialect.Dialect,
 	sourceDir string,
 	outputDir string,
 	fileSystem *afero.Fs,
 	marker string,
 ) *Converter {
 	return &Converter{
-		Dialect:    dialect.NewMySQL(),
-		AutoID:     true,
-		SourceDir:  sourceDir,
-		OutputDir:  outputDir,
-		FileSystem: fileSystem,
-		Marker:     fmt.Sprintf("+%s", marker),
-		TagMaker:   marker,
+		Dialect:      d,
+		AutoID:       true,
+		SourceDir:    sourceDir,
+		OutputDir:    outputDir,
+		SnapshotFile: filepath.Join(outputDir, snapshot.DefaultFilename),
+		FileSystem:   fileSystem,
+		Marker:       fmt.Sprintf("+%s", marker),
+		TagMaker:     marker,
 	}
 }
 
 func (c *Converter) CreateSQL() (err error) {
-	filenames, err := file.GetFiles(c.FileSystem, c.SourceDir)
-	sqlMap, dependencyMap, err := sql.CreateSQL(c.Dialect, c.AutoID, c.Marker, c.TagMaker, filenames)
-	m := migration.NewMigrate(sqlMap, dependencyMap, c.OutputDir)
+	sqlMap, dependencyMap, err := c.createSQL()
+	if err != nil {
+		return
+	}
+	err = c.writeQueries(sqlMap)
+	if err != nil {
+		return
+	}
+	err = c.writeRepositories(sqlMap)
+	if err != nil {
+		return
+	}
+	m := migration.NewMigrate(c.Dialect, sqlMap, dependencyMap, c.OutputDir, c.Versioning)
+	m.Format = c.Format
+	c.logDiagnostics(m.Diagnostics)
 	err = m.WriteFile(c.FileSystem)
+	if err != nil {
+		return
+	}
+	err = snapshot.New(sqlMap, c.SourceDir).Write(c.FileSystem, c.SnapshotFile)
+	return
+}
+
+// DiffSQL writes only the migrations that turn the schema recorded in the snapshot file into the current one, and updates the snapshot.
+func (c *Converter) DiffSQL() (err error) {
+	sqlMap, _, err := c.createSQL()
+	if err != nil {
+		return
+	}
+	err = c.writeQueries(sqlMap)
+	if err != nil {
+		return
+	}
+	err = c.writeRepositories(sqlMap)
+	if err != nil {
+		return
+	}
+	previous, err := snapshot.Read(c.FileSystem, c.SnapshotFile)
+	if err != nil {
+		return
+	}
+	current := snapshot.New(sqlMap, c.SourceDir)
+	schemaDiff := diff.Compare(previous.Schema(), current.Schema())
+	if schemaDiff.IsEmpty() {
+		log.Print("no schema changes")
+		return
+	}
+	latest, err := migration.LatestVersion(c.FileSystem, c.OutputDir)
+	if err != nil {
+		return
+	}
+	positions := map[string]token.Position{} // map[tableName]position of the struct
+	for tableName, s := range sqlMap {
+		positions[tableName] = s.Source.Pos
+	}
+	m := migration.NewDiffMigrate(c.Dialect, schemaDiff, c.OutputDir, c.Versioning, latest, positions)
+	m.Format = c.Format
+	c.logDiagnostics(m.Diagnostics)
+	err = m.WriteFile(c.FileSystem)
+	if err != nil {
+		return
+	}
+	err = current.Write(c.FileSystem, c.SnapshotFile)
+	return
+}
+
+// Schema returns the tables declared by the structs in the source directory.
+func (c *Converter) Schema() (tables []dialect.Table, err error) {
+	sqlMap, _, err := c.createSQL()
+	if err != nil {
+		return
+	}
+	tables = snapshot.New(sqlMap, c.SourceDir).Schema()
+	return
+}
+
+// createSQL creates the statements of the structs in the source directory.
+// Fields left out are logged as warnings, and also when left out on purpose if Verbose is set.
+func (c *Converter) createSQL() (sqlMap map[string]*sql.SQL, dependencyMap map[string]map[string]struct{}, err error) {
+	filenames, err := file.GetFiles(c.FileSystem, c.SourceDir)
+	if err != nil {
+		return
+	}
+	sqlMap, dependencyMap, diags, err := sql.CreateSQL(c.Dialect, c.AutoID, c.Marker, c.TagMaker, filenames)
+	c.logDiagnostics(diags)
+	return
+}
+
+// logDiagnostics logs the warnings, and also the fields left out on purpose if Verbose is set.
+func (c *Converter) logDiagnostics(diags diagnostic.List) {
+	for _, d := range diags {
+		if d.Severity == diagnostic.Warning || (d.Severity == diagnostic.Skip && c.Verbose) {
+			log.Print(d)
+		}
+	}
+}
+
+// writeQueries writes the CRUD statements of the tables to QueryDir if it is set.
+func (c *Converter) writeQueries(sqlMap map[string]*sql.SQL) error {
+	if c.QueryDir == "" {
+		return nil
+	}
+	return query.NewQueries(sqlMap, c.QueryDir).WriteFile(c.FileSystem)
+}
+
+// writeRepositories generates the repositories of the tables in RepoDir if it is set.
+func (c *Converter) writeRepositories(sqlMap map[string]*sql.SQL) error {
+	if c.RepoDir == "" {
+		return nil
+	}
+	importPaths, err := ImportPaths(c.FileSystem, sqlMap)
+	if err != nil {
+		return err
+	}
+	r, err := repository.NewRepositories(c.Dialect, sqlMap, c.RepoDir, importPaths)
+	if err != nil {
+		return err
+	}
+	return r.WriteFile(c.FileSystem)
+}
+
+// ImportPaths returns the import paths of the packages the tables are declared in.
+func ImportPaths(fs *afero.Fs, sqlMap map[string]*sql.SQL) (importPaths map[string]string, err error) {
+	importPaths = map[string]string{} // map[filename]importPath
+	dirs := map[string]string{}       // map[dir]importPath
+	for _, s := range sqlMap {
+		if s.Source.Filename == "" {
+			continue
+		}
+		dir := filepath.Dir(s.Source.Filename)
+		if _, ok := dirs[dir]; !ok {
+			dirs[dir], err = file.ImportPath(fs, dir)
+			if err != nil {
+				return
+			}
+		}
+		importPaths[s.Source.Filename] = dirs[dir]
+	}
 	return
 }
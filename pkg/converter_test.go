@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/migration"
+	"github.com/spf13/afero"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const micropostSource = `package domain
+
+//+test
+type Micropost struct {
+	ID      int64
+	Content string
+	Tag     []Tag
+}
+
+//+test
+type Tag struct {
+	ID   %s
+	Name string
+}
+`
+
+func TestDiffSQLCrossReference(t *testing.T) {
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "domain")
+	outputDir := filepath.Join(dir, "migrations")
+	for _, d := range []string{sourceDir, outputDir} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeSource := func(idType string) {
+		src := fmt.Sprintf(micropostSource, idType)
+		if err := os.WriteFile(filepath.Join(sourceDir, "domain.go"), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fs := afero.NewOsFs()
+	c := NewConverter(dialect.NewPostgres(), sourceDir, outputDir, &fs, "test")
+	c.Versioning = migration.Versioning{Sequence: true}
+	writeSource("int64")
+	if err := c.CreateSQL(); err != nil {
+		t.Fatal(err)
+	}
+	writeSource("int32")
+	if err := c.DiffSQL(); err != nil {
+		t.Fatal(err)
+	}
+
+	up, err := filepath.Glob(filepath.Join(outputDir, "*_alter_micropost_tag_table.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(up) != 1 {
+		t.Fatalf("migrations of micropost_tag = %v, want one", up)
+	}
+	got, err := os.ReadFile(up[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `ALTER TABLE "micropost_tag" ALTER COLUMN "tag_id" TYPE INTEGER`; !strings.Contains(string(got), want) {
+		t.Errorf("%s =\n%s\nwant it to contain\n%s", filepath.Base(up[0]), got, want)
+	}
+}
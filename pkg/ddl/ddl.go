@@ -0,0 +1,759 @@
+package ddl
+
+import (
+	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"strings"
+)
+
+// typeAliases maps PostgreSQL type names to the names the dialects use.
+var typeAliases = map[string]string{
+	"CHARACTER VARYING": "VARCHAR",
+	"INT2":              "SMALLINT",
+	"INT4":              "INTEGER",
+	"INT8":              "BIGINT",
+	"FLOAT4":            "REAL",
+	"FLOAT8":            "DOUBLE PRECISION",
+	"TIMESTAMPTZ":       "TIMESTAMP WITH TIME ZONE",
+}
+
+// serialTypes maps the PostgreSQL serial pseudo-types to the integer type of the column.
+var serialTypes = map[string]string{
+	"SMALLSERIAL": "SMALLINT",
+	"SERIAL":      "INTEGER",
+	"BIGSERIAL":   "BIGINT",
+}
+
+// integerTypes are the MySQL types whose display width is dropped, like in INT(11).
+var integerTypes = map[string]struct{}{
+	"TINYINT":   {},
+	"SMALLINT":  {},
+	"MEDIUMINT": {},
+	"INT":       {},
+	"INTEGER":   {},
+	"BIGINT":    {},
+}
+
+// columnKeywords start a column constraint and so end a DEFAULT expression.
+var columnKeywords = map[string]struct{}{
+	"NOT": {}, "NULL": {}, "PRIMARY": {}, "UNIQUE": {}, "KEY": {}, "REFERENCES": {}, "CHECK": {},
+	"CONSTRAINT": {}, "COMMENT": {}, "AUTO_INCREMENT": {}, "AUTOINCREMENT": {}, "COLLATE": {},
+	"ON": {}, "GENERATED": {}, "CHARACTER": {}, "CHARSET": {}, "INVISIBLE": {}, "VISIBLE": {},
+}
+
+// Parser reads tables from MySQL and PostgreSQL DDL.
+// CREATE TABLE, CREATE INDEX, ALTER TABLE ... ADD and COMMENT ON COLUMN statements are understood, other statements are skipped.
+type Parser struct {
+	tables   []*dialect.Table
+	tableMap map[string]*dialect.Table // map[tableName]table
+	src      string
+}
+
+func NewParser() *Parser {
+	return &Parser{
+		tableMap: map[string]*dialect.Table{},
+	}
+}
+
+// Parse reads the tables from DDL. Parse can be called for several sources, like a file of tables and a file of indexes.
+func Parse(src string) ([]dialect.Table, error) {
+	p := NewParser()
+	if err := p.Parse(src); err != nil {
+		return nil, err
+	}
+	return p.Tables(), nil
+}
+
+// Parse reads the statements of the source.
+func (p *Parser) Parse(src string) error {
+	statements, err := lex(src)
+	if err != nil {
+		return err
+	}
+	p.src = src
+	for _, tokens := range statements {
+		if err := p.parseStatement(tokens); err != nil {
+			return fmt.Errorf("auto-table: line %d: %v", strings.Count(src[:tokens[0].pos], "\n")+1, err)
+		}
+	}
+	return nil
+}
+
+// Tables returns the tables in the order they were created.
+func (p *Parser) Tables() []dialect.Table {
+	tables := make([]dialect.Table, len(p.tables))
+	for i, t := range p.tables {
+		tables[i] = *t
+	}
+	return tables
+}
+
+func (p *Parser) parseStatement(tokens []token) error {
+	s := &stream{tokens: tokens}
+	switch {
+	case s.accept("CREATE"):
+		s.accept("OR", "REPLACE")
+		s.accept("GLOBAL")
+		s.accept("LOCAL")
+		s.accept("TEMPORARY")
+		s.accept("TEMP")
+		s.accept("UNLOGGED")
+		if s.accept("TABLE") {
+			return p.parseCreateTable(s)
+		}
+		unique := s.accept("UNIQUE")
+		if s.accept("INDEX") {
+			return p.parseCreateIndex(s, unique)
+		}
+	case s.accept("ALTER", "TABLE"):
+		return p.parseAlterTable(s)
+	case s.accept("COMMENT", "ON", "COLUMN"):
+		return p.parseComment(s)
+	}
+	return nil
+}
+
+func (p *Parser) parseCreateTable(s *stream) error {
+	s.accept("IF", "NOT", "EXISTS")
+	name, err := s.name()
+	if err != nil {
+		return err
+	}
+	if !s.peek().is("(") {
+		// CREATE TABLE ... AS SELECT and CREATE TABLE ... LIKE copy a table we do not know.
+		return nil
+	}
+	t := &dialect.Table{Name: name}
+	items, rest, err := s.group()
+	if err != nil {
+		return err
+	}
+	for _, item := range split(items) {
+		if len(item) == 0 {
+			continue
+		}
+		if err := p.parseTableElement(t, &stream{tokens: item}); err != nil {
+			return err
+		}
+	}
+	t.Option = p.tableOption(rest)
+
+	if _, ok := p.tableMap[name]; !ok {
+		p.tables = append(p.tables, t)
+	} else {
+		for i, old := range p.tables {
+			if old.Name == name {
+				p.tables[i] = t
+			}
+		}
+	}
+	p.tableMap[name] = t
+	return nil
+}
+
+// parseTableElement parses a column definition or a table constraint.
+func (p *Parser) parseTableElement(t *dialect.Table, s *stream) error {
+	first := s.peek()
+	if first.kind == tokenIdent {
+		switch strings.ToUpper(first.text) {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "KEY", "INDEX", "FOREIGN":
+			return p.parseConstraint(t, s)
+		case "CHECK", "FULLTEXT", "SPATIAL", "EXCLUDE", "LIKE", "PERIOD":
+			return nil
+		}
+	}
+	f, err := p.parseColumn(t, s)
+	if err != nil {
+		return err
+	}
+	t.Fields = append(t.Fields, f)
+	return nil
+}
+
+func (p *Parser) parseColumn(t *dialect.Table, s *stream) (f dialect.Field, err error) {
+	name, err := s.name()
+	if err != nil {
+		return
+	}
+	f = dialect.Field{Table: t.Name, Name: name, Nullable: true}
+	f.Type, f.AutoIncrement = p.parseType(s)
+	// Serial columns are NOT NULL implicitly.
+	f.Nullable = !f.AutoIncrement
+
+	for !s.done() {
+		switch {
+		case s.accept("NOT", "NULL"):
+			f.Nullable = false
+		case s.accept("NULL"):
+			f.Nullable = true
+		case s.accept("DEFAULT"):
+			def, autoIncrement := p.parseDefault(s)
+			f.Default = def
+			f.AutoIncrement = f.AutoIncrement || autoIncrement
+		case s.accept("AUTO_INCREMENT"), s.accept("AUTOINCREMENT"):
+			f.AutoIncrement = true
+		case s.accept("PRIMARY", "KEY"):
+			f.Nullable = false
+			t.PrimaryKeys = append(t.PrimaryKeys, name)
+		case s.accept("UNIQUE"):
+			s.accept("KEY")
+			addIndex(t, dialect.Index{Table: t.Name, Name: fmt.Sprintf("uq_%s_%s", t.Name, name), Columns: []string{name}, Unique: true})
+		case s.accept("REFERENCES"):
+			ref, refColumns, rErr := p.parseReference(s)
+			if rErr != nil {
+				return f, rErr
+			}
+			column := "id"
+			if len(refColumns) > 0 {
+				column = refColumns[0]
+			}
+			addForeignKey(t, name, dialect.ForeignKey{Table: ref, Column: column})
+		case s.accept("COMMENT"):
+			if tok := s.next(); tok.kind == tokenString {
+				f.Comment = tok.text
+			}
+		case s.accept("ON", "UPDATE"):
+			f.Extra = "ON UPDATE " + strings.ToUpper(currentTimestamp(p.parseExpr(s)))
+		case s.accept("GENERATED"):
+			if s.accept("ALWAYS") || s.accept("BY", "DEFAULT") {
+				if s.accept("AS", "IDENTITY") {
+					f.AutoIncrement, f.Nullable = true, false
+					if s.peek().is("(") {
+						s.group()
+					}
+					continue
+				}
+			}
+			// Generated columns are computed from an expression we do not model.
+			s.accept("AS")
+			if s.peek().is("(") {
+				s.group()
+			}
+		case s.accept("CHARACTER", "SET"), s.accept("CHARSET"), s.accept("COLLATE"):
+			s.next()
+		case s.accept("CONSTRAINT"):
+			s.next()
+		case s.peek().is("CHECK"):
+			s.next()
+			if s.peek().is("(") {
+				s.group()
+			}
+		default:
+			s.next()
+		}
+	}
+	return
+}
+
+// parseType parses a column type like VARCHAR(255), DOUBLE PRECISION, INT UNSIGNED or TIMESTAMP(6) WITH TIME ZONE.
+func (p *Parser) parseType(s *stream) (typ string, autoIncrement bool) {
+	var words []string
+	if tok := s.next(); tok.kind == tokenIdent {
+		words = append(words, strings.ToUpper(tok.text))
+	} else {
+		// Quoted user defined type
+		return tok.text, false
+	}
+	switch words[0] {
+	case "DOUBLE":
+		if s.accept("PRECISION") {
+			words = append(words, "PRECISION")
+		}
+	case "CHARACTER", "BIT":
+		if s.accept("VARYING") {
+			words = append(words, "VARYING")
+		}
+	}
+	if name, ok := s.qualified(); ok && len(words) == 1 {
+		// Schema qualified type like public.citext
+		words[0] = strings.ToUpper(name)
+	}
+
+	base := strings.Join(words, " ")
+	if alias, ok := typeAliases[base]; ok {
+		base = alias
+	}
+	if serial, ok := serialTypes[base]; ok {
+		base, autoIncrement = serial, true
+	}
+
+	var args string
+	if s.peek().is("(") {
+		group, _, _ := s.group()
+		parts := make([]string, 0, len(group))
+		for _, item := range split(group) {
+			parts = append(parts, p.raw(item))
+		}
+		args = "(" + strings.Join(parts, ",") + ")"
+	}
+	if _, ok := integerTypes[base]; ok && !(base == "TINYINT" && args == "(1)") {
+		args = ""
+	}
+	typ = base + args
+
+	for {
+		switch {
+		case s.accept("WITH", "TIME", "ZONE"):
+			typ += " WITH TIME ZONE"
+		case s.accept("WITHOUT", "TIME", "ZONE"):
+			// The default of TIMESTAMP and TIME
+		case s.accept("UNSIGNED"):
+			typ += " UNSIGNED"
+		case s.accept("SIGNED"), s.accept("ZEROFILL"):
+		case s.peek().is("[") && s.peekAt(1).is("]"):
+			s.next()
+			s.next()
+			typ += "[]"
+		default:
+			return
+		}
+	}
+}
+
+// parseDefault returns the value of a DEFAULT clause. Strings are unquoted like information_schema reports them.
+// A nextval() default is how pg_dump writes a serial column, so it is reported as auto increment instead.
+func (p *Parser) parseDefault(s *stream) (def string, autoIncrement bool) {
+	start := s.pos
+	expr := p.parseExpr(s)
+	tokens := s.tokens[start:s.pos]
+	switch {
+	case len(tokens) == 0 || strings.EqualFold(expr, "NULL"):
+		return "", false
+	case tokens[0].kind == tokenString && (len(tokens) == 1 || tokens[1].is("::")):
+		return tokens[0].text, false
+	case tokens[0].is("(") && len(tokens) >= 3 && tokens[1].kind == tokenString && tokens[2].is(")"):
+		return tokens[1].text, false
+	case strings.HasPrefix(strings.ToLower(expr), "nextval("):
+		return "", true
+	}
+	return currentTimestamp(expr), false
+}
+
+// currentTimestamp writes current_timestamp() like information_schema reports it, as CURRENT_TIMESTAMP.
+func currentTimestamp(expr string) string {
+	if strings.EqualFold(expr, "current_timestamp()") {
+		return "CURRENT_TIMESTAMP"
+	}
+	return expr
+}
+
+// parseExpr skips an expression up to the next column constraint and returns its source.
+func (p *Parser) parseExpr(s *stream) string {
+	start := s.pos
+	for !s.done() {
+		tok := s.peek()
+		if tok.kind == tokenIdent && s.pos > start {
+			if _, ok := columnKeywords[strings.ToUpper(tok.text)]; ok {
+				break
+			}
+		}
+		if tok.is("(") {
+			s.group()
+			continue
+		}
+		s.next()
+	}
+	return p.raw(s.tokens[start:s.pos])
+}
+
+// parseConstraint parses a table constraint or a MySQL index definition.
+func (p *Parser) parseConstraint(t *dialect.Table, s *stream) error {
+	var name string
+	if s.accept("CONSTRAINT") {
+		if tok := s.peek(); tok.isName() && !tok.is("PRIMARY") && !tok.is("UNIQUE") && !tok.is("FOREIGN") && !tok.is("CHECK") {
+			name = s.next().text
+		}
+	}
+	switch {
+	case s.accept("PRIMARY", "KEY"):
+		columns, err := p.parseColumns(s)
+		if err != nil {
+			return err
+		}
+		t.PrimaryKeys = columns
+		for i, f := range t.Fields {
+			for _, c := range columns {
+				if f.Name == c {
+					t.Fields[i].Nullable = false
+				}
+			}
+		}
+	case s.peek().is("UNIQUE"), s.peek().is("KEY"), s.peek().is("INDEX"):
+		unique := s.accept("UNIQUE")
+		if !s.accept("KEY") {
+			s.accept("INDEX")
+		}
+		if tok := s.peek(); tok.isName() && !tok.is("USING") {
+			name = s.next().text
+		}
+		if s.accept("USING") {
+			s.next()
+		}
+		columns, err := p.parseColumns(s)
+		if err != nil || columns == nil {
+			return err
+		}
+		if name == "" {
+			prefix := "idx"
+			if unique {
+				prefix = "uq"
+			}
+			name = fmt.Sprintf("%s_%s_%s", prefix, t.Name, strings.Join(columns, "_"))
+		}
+		addIndex(t, dialect.Index{Table: t.Name, Name: name, Columns: columns, Unique: unique})
+	case s.accept("FOREIGN", "KEY"):
+		if tok := s.peek(); tok.isName() {
+			s.next()
+		}
+		columns, err := p.parseColumns(s)
+		if err != nil {
+			return err
+		}
+		if !s.accept("REFERENCES") {
+			return fmt.Errorf("REFERENCES expected after FOREIGN KEY")
+		}
+		ref, refColumns, err := p.parseReference(s)
+		if err != nil {
+			return err
+		}
+		for i, c := range columns {
+			column := "id"
+			if i < len(refColumns) {
+				column = refColumns[i]
+			}
+			addForeignKey(t, c, dialect.ForeignKey{Table: ref, Column: column})
+		}
+	}
+	return nil
+}
+
+// parseColumns parses a parenthesized list of index columns.
+// A nil list is returned for expression indexes, which cannot be declared by tags.
+func (p *Parser) parseColumns(s *stream) ([]string, error) {
+	if !s.peek().is("(") {
+		return nil, fmt.Errorf("column list expected, found %q", s.peek().text)
+	}
+	group, _, err := s.group()
+	if err != nil {
+		return nil, err
+	}
+	var columns []string
+	for _, item := range split(group) {
+		if len(item) == 0 || !item[0].isName() {
+			return nil, nil
+		}
+		// A prefix length like name(10) is allowed, a function of the column is not.
+		if len(item) > 1 && (item[1].is("::") || item[1].is("(") && !(len(item) > 3 && item[2].kind == tokenNumber && item[3].is(")"))) {
+			return nil, nil
+		}
+		columns = append(columns, item[0].text)
+	}
+	return columns, nil
+}
+
+// parseReference parses the table and columns after REFERENCES and skips the referential actions.
+func (p *Parser) parseReference(s *stream) (table string, columns []string, err error) {
+	table, err = s.name()
+	if err != nil {
+		return
+	}
+	if s.peek().is("(") {
+		columns, err = p.parseColumns(s)
+	}
+	for s.accept("ON") {
+		s.next() // DELETE or UPDATE
+		if !s.accept("SET", "NULL") && !s.accept("SET", "DEFAULT") && !s.accept("NO", "ACTION") {
+			s.next()
+		}
+	}
+	return
+}
+
+func (p *Parser) parseCreateIndex(s *stream, unique bool) error {
+	s.accept("CONCURRENTLY")
+	s.accept("IF", "NOT", "EXISTS")
+	var name string
+	if !s.peek().is("ON") {
+		var err error
+		if name, err = s.name(); err != nil {
+			return err
+		}
+	}
+	if s.accept("USING") {
+		s.next()
+	}
+	if !s.accept("ON") {
+		return fmt.Errorf("ON expected in CREATE INDEX")
+	}
+	s.accept("ONLY")
+	tableName, err := s.name()
+	if err != nil {
+		return err
+	}
+	if s.accept("USING") {
+		s.next()
+	}
+	columns, err := p.parseColumns(s)
+	if err != nil || columns == nil {
+		return err
+	}
+	t, ok := p.tableMap[tableName]
+	if !ok {
+		return nil
+	}
+	if name == "" {
+		name = fmt.Sprintf("%s_%s_idx", tableName, strings.Join(columns, "_"))
+	}
+	addIndex(t, dialect.Index{Table: tableName, Name: name, Columns: columns, Unique: unique})
+	return nil
+}
+
+func (p *Parser) parseAlterTable(s *stream) error {
+	s.accept("IF", "EXISTS")
+	s.accept("ONLY")
+	name, err := s.name()
+	if err != nil {
+		return err
+	}
+	t, ok := p.tableMap[name]
+	if !ok {
+		return nil
+	}
+	for _, action := range split(s.tokens[s.pos:]) {
+		as := &stream{tokens: action}
+		switch {
+		case as.accept("ADD", "COLUMN"):
+			as.accept("IF", "NOT", "EXISTS")
+			if err := p.parseTableElement(t, as); err != nil {
+				return err
+			}
+		case as.accept("ADD"):
+			if err := p.parseTableElement(t, as); err != nil {
+				return err
+			}
+		case as.accept("ALTER"):
+			as.accept("COLUMN")
+			column, err := as.name()
+			if err != nil {
+				return err
+			}
+			p.alterColumn(t, column, as)
+		}
+	}
+	return nil
+}
+
+// alterColumn applies the ALTER COLUMN actions pg_dump writes for defaults, serials and identities.
+func (p *Parser) alterColumn(t *dialect.Table, column string, s *stream) {
+	var f *dialect.Field
+	for i := range t.Fields {
+		if t.Fields[i].Name == column {
+			f = &t.Fields[i]
+		}
+	}
+	if f == nil {
+		return
+	}
+	switch {
+	case s.accept("SET", "DEFAULT"):
+		def, autoIncrement := p.parseDefault(s)
+		f.Default = def
+		f.AutoIncrement = f.AutoIncrement || autoIncrement
+	case s.accept("DROP", "DEFAULT"):
+		f.Default = ""
+	case s.accept("SET", "NOT", "NULL"):
+		f.Nullable = false
+	case s.accept("DROP", "NOT", "NULL"):
+		f.Nullable = true
+	case s.accept("ADD", "GENERATED"):
+		f.AutoIncrement = true
+	}
+}
+
+func (p *Parser) parseComment(s *stream) error {
+	var parts []string
+	for {
+		tok := s.next()
+		if !tok.isName() {
+			return fmt.Errorf("column name expected in COMMENT ON COLUMN")
+		}
+		parts = append(parts, tok.text)
+		if !s.accept(".") {
+			break
+		}
+	}
+	if len(parts) < 2 || !s.accept("IS") {
+		return fmt.Errorf("table.column IS expected in COMMENT ON COLUMN")
+	}
+	comment := s.next()
+	t, ok := p.tableMap[parts[len(parts)-2]]
+	if !ok {
+		return nil
+	}
+	for i := range t.Fields {
+		if t.Fields[i].Name == parts[len(parts)-1] && comment.kind == tokenString {
+			t.Fields[i].Comment = comment.text
+		}
+	}
+	return nil
+}
+
+// tableOption returns the table options after the column list, like ENGINE=InnoDB.
+// The AUTO_INCREMENT counter of dumps is dropped since it is data, not schema.
+func (p *Parser) tableOption(tokens []token) string {
+	var options []string
+	for _, item := range splitOptions(tokens) {
+		if len(item) == 0 || item[0].is("AUTO_INCREMENT") {
+			continue
+		}
+		options = append(options, p.raw(item))
+	}
+	return strings.Join(options, " ")
+}
+
+// raw returns the source of the tokens.
+func (p *Parser) raw(tokens []token) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(p.src[tokens[0].pos:tokens[len(tokens)-1].end])
+}
+
+func addIndex(t *dialect.Table, index dialect.Index) {
+	for i, idx := range t.Indexes {
+		if idx.Name == index.Name {
+			t.Indexes[i] = index
+			return
+		}
+	}
+	t.Indexes = append(t.Indexes, index)
+}
+
+func addForeignKey(t *dialect.Table, column string, reference dialect.ForeignKey) {
+	if t.ForeignKeys == nil {
+		t.ForeignKeys = map[string]dialect.ForeignKey{}
+	}
+	t.ForeignKeys[column] = reference
+}
+
+// split splits tokens at the commas outside of parentheses.
+func split(tokens []token) (items [][]token) {
+	depth, start := 0, 0
+	for i, tok := range tokens {
+		switch {
+		case tok.is("("):
+			depth++
+		case tok.is(")"):
+			depth--
+		case tok.is(",") && depth == 0:
+			items = append(items, tokens[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, tokens[start:])
+}
+
+// splitOptions splits MySQL table options like ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 into single options.
+func splitOptions(tokens []token) (items [][]token) {
+	start := 0
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].is("=") && i+1 < len(tokens) {
+			i++
+			items = append(items, tokens[start:i+1])
+			start = i + 1
+			continue
+		}
+		if tokens[i].is(",") {
+			items = append(items, tokens[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		items = append(items, tokens[start:])
+	}
+	return
+}
+
+// stream reads the tokens of a statement.
+type stream struct {
+	tokens []token
+	pos    int
+}
+
+func (s *stream) done() bool {
+	return s.pos >= len(s.tokens)
+}
+
+func (s *stream) peek() token {
+	return s.peekAt(0)
+}
+
+func (s *stream) peekAt(n int) token {
+	if s.pos+n >= len(s.tokens) {
+		return token{kind: tokenPunct}
+	}
+	return s.tokens[s.pos+n]
+}
+
+func (s *stream) next() token {
+	tok := s.peek()
+	if !s.done() {
+		s.pos++
+	}
+	return tok
+}
+
+// accept consumes the keywords if the stream continues with all of them.
+func (s *stream) accept(keywords ...string) bool {
+	for i, k := range keywords {
+		if tok := s.peekAt(i); tok.kind != tokenIdent && tok.kind != tokenPunct || !tok.is(k) {
+			return false
+		}
+	}
+	s.pos += len(keywords)
+	return true
+}
+
+// name reads a possibly schema qualified name and returns its last part.
+func (s *stream) name() (string, error) {
+	tok := s.next()
+	if !tok.isName() {
+		return "", fmt.Errorf("name expected, found %q", tok.text)
+	}
+	name := tok.text
+	if qualified, ok := s.qualified(); ok {
+		name = qualified
+	}
+	return name, nil
+}
+
+// qualified reads the rest of a dotted name.
+func (s *stream) qualified() (name string, ok bool) {
+	for s.peek().is(".") && s.peekAt(1).isName() {
+		s.next()
+		name, ok = s.next().text, true
+	}
+	return
+}
+
+// group reads a parenthesized group and returns its contents and the tokens after it.
+func (s *stream) group() (contents []token, rest []token, err error) {
+	if !s.accept("(") {
+		return nil, nil, fmt.Errorf("( expected")
+	}
+	start, depth := s.pos, 1
+	for ; !s.done(); s.pos++ {
+		switch {
+		case s.tokens[s.pos].is("("):
+			depth++
+		case s.tokens[s.pos].is(")"):
+			depth--
+		}
+		if depth == 0 {
+			contents = s.tokens[start:s.pos]
+			s.pos++
+			return contents, s.tokens[s.pos:], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("parenthesis not closed")
+}
@@ -0,0 +1,100 @@
+package ddl
+
+import (
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    []dialect.Table
+		wantErr string
+	}{
+		{
+			name: "mysql dump",
+			src: "CREATE TABLE `user` (\n" +
+				"  `id` BIGINT(20) NOT NULL AUTO_INCREMENT,\n" +
+				"  `name` VARCHAR(255) NOT NULL DEFAULT 'guest' COMMENT 'login name',\n" +
+				"  `bio` TEXT,\n" +
+				"  `updated` DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+				"  PRIMARY KEY (`id`),\n" +
+				"  UNIQUE KEY `user_name_key` (`name`)\n" +
+				") ENGINE=InnoDB AUTO_INCREMENT=12 DEFAULT CHARSET=utf8mb4;",
+			want: []dialect.Table{
+				{
+					Name: "user",
+					Fields: []dialect.Field{
+						{Table: "user", Name: "id", Type: "BIGINT", AutoIncrement: true},
+						{Table: "user", Name: "name", Type: "VARCHAR(255)", Default: "guest", Comment: "login name"},
+						{Table: "user", Name: "bio", Type: "TEXT", Nullable: true},
+						{Table: "user", Name: "updated", Type: "DATETIME", Default: "CURRENT_TIMESTAMP", Extra: "ON UPDATE CURRENT_TIMESTAMP", Nullable: true},
+					},
+					PrimaryKeys: []string{"id"},
+					Indexes:     []dialect.Index{{Table: "user", Name: "user_name_key", Columns: []string{"name"}, Unique: true}},
+					Option:      "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+				},
+			},
+		},
+		{
+			name: "postgres serial, inline reference, index and comment",
+			src: "CREATE TABLE post (id bigserial PRIMARY KEY, user_id int8 NOT NULL REFERENCES \"user\" (id), title character varying(80));\n" +
+				"CREATE INDEX post_title_idx ON post (title);\n" +
+				"COMMENT ON COLUMN post.title IS 'headline';",
+			want: []dialect.Table{
+				{
+					Name: "post",
+					Fields: []dialect.Field{
+						{Table: "post", Name: "id", Type: "BIGINT", AutoIncrement: true},
+						{Table: "post", Name: "user_id", Type: "BIGINT"},
+						{Table: "post", Name: "title", Type: "VARCHAR(80)", Comment: "headline", Nullable: true},
+					},
+					PrimaryKeys: []string{"id"},
+					ForeignKeys: map[string]dialect.ForeignKey{"user_id": {Table: "user", Column: "id"}},
+					Indexes:     []dialect.Index{{Table: "post", Name: "post_title_idx", Columns: []string{"title"}}},
+				},
+			},
+		},
+		{
+			name: "foreign key added by alter table, other statements skipped",
+			src: "CREATE TABLE a (id INT NOT NULL, b_id INT, PRIMARY KEY (id));\n" +
+				"ALTER TABLE a ADD CONSTRAINT a_b_fkey FOREIGN KEY (b_id) REFERENCES b (id);\n" +
+				"CREATE VIEW v AS SELECT 1;",
+			want: []dialect.Table{
+				{
+					Name: "a",
+					Fields: []dialect.Field{
+						{Table: "a", Name: "id", Type: "INT"},
+						{Table: "a", Name: "b_id", Type: "INT", Nullable: true},
+					},
+					PrimaryKeys: []string{"id"},
+					ForeignKeys: map[string]dialect.ForeignKey{"b_id": {Table: "b", Column: "id"}},
+				},
+			},
+		},
+		{
+			name:    "unclosed parenthesis",
+			src:     "CREATE TABLE a (id INT",
+			wantErr: "auto-table: line 1: parenthesis not closed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.src)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Parse() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenIdent tokenKind = iota
+	tokenQuotedIdent
+	tokenString
+	tokenNumber
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string // Identifiers and strings without quotes, punctuation as is
+	pos  int    // Offset in the source
+	end  int
+}
+
+// is reports whether the token is the keyword or punctuation, ignoring case.
+func (t token) is(s string) bool {
+	return (t.kind == tokenIdent || t.kind == tokenPunct) && strings.EqualFold(t.text, s)
+}
+
+// isName reports whether the token can be the name of a table, column or index.
+func (t token) isName() bool {
+	return t.kind == tokenIdent || t.kind == tokenQuotedIdent || t.kind == tokenString
+}
+
+// lex splits the source into statements of tokens. Comments are dropped, including MySQL's /*! ... */ conditional comments.
+func lex(src string) (statements [][]token, err error) {
+	var current []token
+	line := func(pos int) int {
+		return strings.Count(src[:pos], "\n") + 1
+	}
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case strings.HasPrefix(src[i:], "--"):
+			j := strings.IndexByte(src[i:], '\n')
+			if j < 0 {
+				j = len(src) - i
+			}
+			i += j
+		case strings.HasPrefix(src[i:], "/*"):
+			j := strings.Index(src[i+2:], "*/")
+			if j < 0 {
+				return nil, fmt.Errorf("auto-table: line %d: comment not terminated", line(i))
+			}
+			i += j + 4
+		case c == ';':
+			if len(current) > 0 {
+				statements = append(statements, current)
+				current = nil
+			}
+			i++
+		case c == '\'':
+			s, end, ok := lexQuoted(src, i, '\'', true)
+			if !ok {
+				return nil, fmt.Errorf("auto-table: line %d: string not terminated", line(i))
+			}
+			current = append(current, token{kind: tokenString, text: s, pos: i, end: end})
+			i = end
+		case c == '"' || c == '`':
+			s, end, ok := lexQuoted(src, i, c, false)
+			if !ok {
+				return nil, fmt.Errorf("auto-table: line %d: identifier not terminated", line(i))
+			}
+			current = append(current, token{kind: tokenQuotedIdent, text: s, pos: i, end: end})
+			i = end
+		case c == '$' && dollarTag(src[i:]) != "":
+			// PostgreSQL dollar quoted string, like the body of a function
+			tag := dollarTag(src[i:])
+			j := strings.Index(src[i+len(tag):], tag)
+			if j < 0 {
+				return nil, fmt.Errorf("auto-table: line %d: string not terminated", line(i))
+			}
+			end := i + len(tag) + j + len(tag)
+			current = append(current, token{kind: tokenString, text: src[i+len(tag) : end-len(tag)], pos: i, end: end})
+			i = end
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(src) && isIdentPart(src[j]) {
+				j++
+			}
+			current = append(current, token{kind: tokenIdent, text: src[i:j], pos: i, end: j})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.' || src[j] == 'e' || src[j] == 'E') {
+				j++
+			}
+			current = append(current, token{kind: tokenNumber, text: src[i:j], pos: i, end: j})
+			i = j
+		case strings.HasPrefix(src[i:], "::"):
+			current = append(current, token{kind: tokenPunct, text: "::", pos: i, end: i + 2})
+			i += 2
+		default:
+			current = append(current, token{kind: tokenPunct, text: string(c), pos: i, end: i + 1})
+			i++
+		}
+	}
+	if len(current) > 0 {
+		statements = append(statements, current)
+	}
+	return
+}
+
+// lexQuoted reads a quoted string or identifier starting at i. A doubled quote is an escaped quote.
+func lexQuoted(src string, i int, quote byte, backslash bool) (s string, end int, ok bool) {
+	var b strings.Builder
+	for j := i + 1; j < len(src); j++ {
+		c := src[j]
+		if backslash && c == '\\' && j+1 < len(src) {
+			j++
+			b.WriteByte(unescape(src[j]))
+			continue
+		}
+		if c == quote {
+			if j+1 < len(src) && src[j+1] == quote {
+				b.WriteByte(quote)
+				j++
+				continue
+			}
+			return b.String(), j + 1, true
+		}
+		b.WriteByte(c)
+	}
+	return "", 0, false
+}
+
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case '0':
+		return 0
+	}
+	return c
+}
+
+// dollarTag returns the opening tag of a dollar quoted string like $$ or $body$, or an empty string.
+func dollarTag(s string) string {
+	for j := 1; j < len(s); j++ {
+		if s[j] == '$' {
+			return s[:j+1]
+		}
+		if !isIdentPart(s[j]) || s[j] == '$' {
+			return ""
+		}
+	}
+	return ""
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9' || c == '$'
+}
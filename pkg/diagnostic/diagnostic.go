@@ -0,0 +1,127 @@
+package diagnostic
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Severity tells whether a diagnostic fails the generation.
+type Severity int
+
+const (
+	Skip    Severity = iota // Something left out on purpose, like a field tagged "-" or an unexported field
+	Warning                 // Something left out that was likely meant to be a column, the generation goes on without it
+	Error                   // Something the generation cannot go on with
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Skip:
+		return "skip"
+	case Warning:
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic is a problem found at a position of the source files.
+type Diagnostic struct {
+	Pos      token.Position
+	Severity Severity
+	Message  string
+}
+
+// String formats the diagnostic like the go tools do, like model/user.go:12:2: error: multiple IDs exist.
+func (d Diagnostic) String() string {
+	if !d.Pos.IsValid() {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Pos, d.Severity, d.Message)
+}
+
+// List collects the diagnostics in the order they are found.
+type List []Diagnostic
+
+// Report adds err at the position, with the severity it was made with by Skipf or Warnf, or as an error.
+func (l *List) Report(pos token.Position, err error) {
+	*l = append(*l, Diagnostic{
+		Pos:      pos,
+		Severity: SeverityOf(err),
+		Message:  strings.TrimPrefix(err.Error(), "auto-table: "),
+	})
+}
+
+// Sort orders the diagnostics by file and position.
+func (l List) Sort() {
+	sort.SliceStable(l, func(i, j int) bool {
+		a, b := l[i].Pos, l[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// Filter returns the diagnostics of the severity.
+func (l List) Filter(severity Severity) (filtered List) {
+	for _, d := range l {
+		if d.Severity == severity {
+			filtered = append(filtered, d)
+		}
+	}
+	return
+}
+
+// Err returns the errors of the list as an Errors, or nil if there are none.
+func (l List) Err() error {
+	if errs := l.Filter(Error); len(errs) > 0 {
+		return Errors(errs)
+	}
+	return nil
+}
+
+// Errors is returned when the source files have errors, listing all of them.
+type Errors List
+
+func (e Errors) Error() string {
+	lines := make([]string, len(e))
+	for i, d := range e {
+		lines[i] = d.String()
+	}
+	return fmt.Sprintf("auto-table: %d error(s) in the source files:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// severityError is an error reported with a severity below Error.
+type severityError struct {
+	severity Severity
+	message  string
+}
+
+func (e *severityError) Error() string {
+	return e.message
+}
+
+// Skipf makes an error for something left out on purpose.
+func Skipf(format string, args ...interface{}) error {
+	return &severityError{severity: Skip, message: fmt.Sprintf(format, args...)}
+}
+
+// Warnf makes an error that is reported as a warning.
+func Warnf(format string, args ...interface{}) error {
+	return &severityError{severity: Warning, message: fmt.Sprintf(format, args...)}
+}
+
+// SeverityOf returns the severity err is reported with, Error unless it was made by Skipf or Warnf.
+func SeverityOf(err error) Severity {
+	var s *severityError
+	if errors.As(err, &s) {
+		return s.severity
+	}
+	return Error
+}
@@ -1,5 +1,12 @@
 package dialect
 
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
 type Dialect interface {
 	ColumnType(name string) string
 	GoType(name string, nullable bool) string
@@ -42,40 +49,141 @@ type Transactioner interface {
 	Rollback() error
 }
 
+// Migrator is implemented by dialects that can apply migrations to a live database.
+type Migrator interface {
+	// TransactionalDDL reports whether schema changes are rolled back together with the transaction they run in.
+	TransactionalDDL() bool
+	Begin(conn *sql.Conn) (Transactioner, error)
+	// Lock takes an advisory lock by name for the session of conn, waiting until it is available.
+	Lock(conn *sql.Conn, name string) error
+	Unlock(conn *sql.Conn, name string) error
+	// HasTable reports whether the table exists in the database of conn.
+	HasTable(conn *sql.Conn, name string) (bool, error)
+}
+
+// SessionModifier is implemented by dialects with statements that change the session and take no effect in a transaction,
+// like PRAGMA foreign_keys of SQLite. The runner runs them on the connection before and after the transaction of a migration.
+type SessionModifier interface {
+	IsSessionStatement(query string) bool
+}
+
 type PrimaryKeyModifier interface {
 	ModifyPrimaryKeySQL(oldPrimaryKeys, newPrimaryKeys []Field) []string
 }
 
+// ForeignKeyModifier is implemented by dialects that can add and drop the foreign keys of existing tables.
+// Columns are named as in the tables, and the constraints are named by ForeignKeyName.
+type ForeignKeyModifier interface {
+	AddForeignKeySQL(table string, column string, reference ForeignKey) []string
+	DropForeignKeySQL(table string, column string) []string
+}
+
+// RecordQuerier is implemented by dialects that generate record queries beyond the basic CRUD statements.
+type RecordQuerier interface {
+	// UpsertSQL inserts a record, or updates it if a record with the same primary key exists.
+	UpsertSQL(table Table) []string
+	BatchCreateSQL(table Table, rows int) []string
+	// PaginateSQL returns the records after a primary key in the order of the primary key, with the page size as the last parameter.
+	PaginateSQL(table Table) []string
+	FindByIndexSQL(table Table, index Index) []string
+}
+
+// RelationQuerier is implemented by dialects that generate the statements following foreign keys.
+// Columns are named as in the tables, not as the struct fields.
+type RelationQuerier interface {
+	// ParentSQL finds the record of parent that the column of table references, by the primary key of the record of table.
+	ParentSQL(table Table, column string, parent Table, parentColumn string) []string
+	// ChildrenSQL finds the records of table whose column references a value.
+	ChildrenSQL(table Table, column string) []string
+	// ThroughSQL finds the records of table linked to a value by the cross reference table through,
+	// whose column from references the value and whose column to references column of table.
+	ThroughSQL(table Table, column string, through Table, from string, to string) []string
+}
+
+// Inspector is implemented by dialects that can read the tables of a live database.
+type Inspector interface {
+	Inspect(db *sql.DB) ([]Table, error)
+}
+
+// TableRebuilder is implemented by dialects that cannot alter columns in place and instead recreate the whole table.
+type TableRebuilder interface {
+	RebuildTableSQL(oldTable, newTable Table) []string
+	// ForeignKeysSQL turns the enforcement of foreign keys on or off around rebuilds.
+	// The statements change the session, so they start and end a migration, see SessionModifier.
+	ForeignKeysSQL(on bool) []string
+}
+
+// IsTimestampColumn reports whether the column is one of the created_at and updated_at columns every dialect adds to a table.
+func IsTimestampColumn(name string) bool {
+	return name == "created_at" || name == "updated_at"
+}
+
+// ForeignKeyNames returns the names the foreign keys of the table are keyed by, sorted so that the constraints are written in the same order on every run.
+func ForeignKeyNames(table Table) []string {
+	names := make([]string, 0, len(table.ForeignKeys))
+	for name := range table.ForeignKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ForeignKeyName names the foreign key constraint of a column, like micropost_author_id_fkey, which is the name PostgreSQL gives it.
+func ForeignKeyName(table string, column string) string {
+	return fmt.Sprintf("%s_%s_fkey", table, column)
+}
+
+// NeedsBackfill reports whether adding the column to a table with rows fails unless the rows are given a value, as NOT NULL columns without a default do.
+func NeedsBackfill(field Field) bool {
+	return !field.Nullable && field.Default == "" && !field.AutoIncrement
+}
+
+// zeroValue returns the literal of the zero value of the Go type the column type is scanned into, or "" if the type has none.
+func zeroValue(d Dialect, typ string) string {
+	switch d.GoType(typ, false) {
+	case "string", "[]byte":
+		return "''"
+	case "bool":
+		return "FALSE"
+	case "time.Time":
+		return "CURRENT_TIMESTAMP"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		return "0"
+	}
+	return ""
+}
+
 type Table struct {
-	Name        string
-	Fields      []Field
-	PrimaryKeys []string
-	ForeignKeys map[string]ForeignKey //map[columnName]reference
-	Option      string
+	Name        string                `json:"name"`
+	Fields      []Field               `json:"fields"`
+	PrimaryKeys []string              `json:"primaryKeys,omitempty"`
+	ForeignKeys map[string]ForeignKey `json:"foreignKeys,omitempty"` //map[columnName]reference
+	Indexes     []Index               `json:"indexes,omitempty"`
+	Option      string                `json:"option,omitempty"`
 }
 
 type ForeignKey struct {
-	Table  string
-	Column string
+	Table  string `json:"table"`
+	Column string `json:"column"`
 }
 
 type Field struct {
-	Table         string
-	Name          string
-	Type          string
-	Comment       string
-	AutoIncrement bool
-	Default       string
-	Extra         string
-	Nullable      bool
-	ForeignKey    *ForeignKey
+	Table         string      `json:"table"`
+	Name          string      `json:"name"`
+	Type          string      `json:"type"`
+	Comment       string      `json:"comment,omitempty"`
+	AutoIncrement bool        `json:"autoIncrement,omitempty"`
+	Default       string      `json:"default,omitempty"`
+	Extra         string      `json:"extra,omitempty"`
+	Nullable      bool        `json:"nullable,omitempty"`
+	ForeignKey    *ForeignKey `json:"foreignKey,omitempty"`
 }
 
 type Index struct {
-	Table   string
-	Name    string
-	Columns []string
-	Unique  bool
+	Table   string   `json:"table"`
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
 }
 
 type ColumnType struct {
@@ -147,3 +255,77 @@ func (c *ColumnType) filteredNullableGoTypes() []string {
 	}
 	return ret
 }
+
+// NewColumnSchema returns the column schema of a field of the table, for use with Dialect.ImportPackage.
+func NewColumnSchema(table Table, field Field) ColumnSchema {
+	return &fieldColumnSchema{table: table, field: field}
+}
+
+type fieldColumnSchema struct {
+	table Table
+	field Field
+}
+
+func (schema *fieldColumnSchema) TableName() string {
+	return schema.table.Name
+}
+
+func (schema *fieldColumnSchema) ColumnName() string {
+	return schema.field.Name
+}
+
+func (schema *fieldColumnSchema) ColumnType() string {
+	return schema.field.Type
+}
+
+// DataType returns the type name without its parameters and attributes, like varchar for VARCHAR(255).
+func (schema *fieldColumnSchema) DataType() string {
+	typ := strings.ToLower(schema.field.Type)
+	if i := strings.IndexByte(typ, '('); i >= 0 {
+		typ = typ[:i]
+	}
+	for _, attr := range []string{" unsigned", " zerofill"} {
+		typ = strings.TrimSuffix(typ, attr)
+	}
+	return strings.TrimSpace(typ)
+}
+
+func (schema *fieldColumnSchema) IsPrimaryKey() bool {
+	for _, pk := range schema.table.PrimaryKeys {
+		if pk == schema.field.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (schema *fieldColumnSchema) IsAutoIncrement() bool {
+	return schema.field.AutoIncrement
+}
+
+func (schema *fieldColumnSchema) Index() (name string, unique bool, ok bool) {
+	for _, idx := range schema.table.Indexes {
+		for _, column := range idx.Columns {
+			if column == schema.field.Name {
+				return idx.Name, idx.Unique, true
+			}
+		}
+	}
+	return "", false, false
+}
+
+func (schema *fieldColumnSchema) Default() (string, bool) {
+	return schema.field.Default, schema.field.Default != ""
+}
+
+func (schema *fieldColumnSchema) IsNullable() bool {
+	return schema.field.Nullable
+}
+
+func (schema *fieldColumnSchema) Extra() (string, bool) {
+	return schema.field.Extra, schema.field.Extra != ""
+}
+
+func (schema *fieldColumnSchema) Comment() (string, bool) {
+	return schema.field.Comment, schema.field.Comment != ""
+}
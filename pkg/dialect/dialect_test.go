@@ -0,0 +1,163 @@
+package dialect
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+// goldenTables are created by every dialect, with the table option of the dialect set on the last one.
+var goldenTables = []Table{
+	{
+		Name: "user",
+		Fields: []Field{
+			{Table: "user", Name: "id", Type: "BIGINT", AutoIncrement: true},
+			{Table: "user", Name: "name", Type: "VARCHAR(255)", Default: "guest", Comment: "login name"},
+			{Table: "user", Name: "bio", Type: "TEXT", Nullable: true},
+		},
+		PrimaryKeys: []string{"id"},
+	},
+	{
+		Name: "micropost",
+		Fields: []Field{
+			{Table: "micropost", Name: "id", Type: "BIGINT", AutoIncrement: true},
+			{Table: "micropost", Name: "author_id", Type: "BIGINT"},
+			{Table: "micropost", Name: "editor_id", Type: "BIGINT", Nullable: true},
+			{Table: "micropost", Name: "content", Type: "TEXT"},
+		},
+		PrimaryKeys: []string{"id"},
+		ForeignKeys: map[string]ForeignKey{
+			"AuthorID": {Table: "User", Column: "ID"},
+			"EditorID": {Table: "User", Column: "ID"},
+		},
+	},
+	{
+		Name: "micropost_tag",
+		Fields: []Field{
+			{Table: "micropost_tag", Name: "micropost_id", Type: "BIGINT"},
+			{Table: "micropost_tag", Name: "tag_id", Type: "BIGINT"},
+		},
+		PrimaryKeys: []string{"micropost_id", "tag_id"},
+	},
+	{
+		Name: "country",
+		Fields: []Field{
+			{Table: "country", Name: "code", Type: "VARCHAR(2)"},
+			{Table: "country", Name: "name", Type: "VARCHAR(80)"},
+		},
+		PrimaryKeys: []string{"code"},
+	},
+}
+
+func TestCreateTableSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		option  string
+	}{
+		{name: "mysql", dialect: NewMySQL(), option: "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"},
+		{name: "postgres", dialect: NewPostgres(), option: "WITH (fillfactor = 70)"},
+		{name: "sqlite", dialect: NewSQLite(), option: "WITHOUT ROWID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var statements []string
+			for i, table := range goldenTables {
+				if i == len(goldenTables)-1 {
+					table.Option = tt.option
+				}
+				statements = append(statements, tt.dialect.CreateTableSQL(table)...)
+			}
+			checkGolden(t, filepath.Join("testdata", "create_table", tt.name+".sql"), strings.Join(statements, "\n\n")+"\n")
+		})
+	}
+}
+
+func TestRecordSQL(t *testing.T) {
+	nameIndex := Index{Table: "user", Name: "user_name_idx", Columns: []string{"name"}, Unique: true}
+	for _, tt := range []struct {
+		name    string
+		dialect Dialect
+	}{
+		{name: "mysql", dialect: NewMySQL()},
+		{name: "postgres", dialect: NewPostgres()},
+		{name: "sqlite", dialect: NewSQLite()},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var blocks []string
+			for _, table := range goldenTables {
+				statements := [][]string{
+					tt.dialect.FindAllSQL(table),
+					tt.dialect.FindSQL(table),
+					tt.dialect.CreateSQL(table),
+					tt.dialect.UpdateSQL(table),
+					tt.dialect.DeleteSQL(table),
+				}
+				if querier, ok := tt.dialect.(RecordQuerier); ok {
+					statements = append(statements,
+						querier.UpsertSQL(table),
+						querier.BatchCreateSQL(table, 2),
+						querier.PaginateSQL(table),
+					)
+					if table.Name == nameIndex.Table {
+						statements = append(statements, querier.FindByIndexSQL(table, nameIndex))
+					}
+				}
+				block := []string{"-- " + table.Name}
+				for _, s := range statements {
+					block = append(block, s...)
+				}
+				blocks = append(blocks, strings.Join(block, "\n"))
+			}
+			checkGolden(t, filepath.Join("testdata", "record", tt.name+".sql"), strings.Join(blocks, "\n\n")+"\n")
+		})
+	}
+}
+
+// checkGolden compares got with the golden file, after rewriting the file with got if -update is set.
+func checkGolden(t *testing.T, golden string, got string) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s =\n%s\nwant\n%s", golden, got, want)
+	}
+}
+
+func TestSQLiteColumnSQL(t *testing.T) {
+	d := NewSQLite()
+	d.CreateTableSQL(goldenTables[0])
+	bio := goldenTables[0].Fields[2]
+	renamed := bio
+	renamed.Name = "profile"
+	tests := []struct {
+		name string
+		got  []string
+		want string
+	}{
+		{name: "add column", got: d.AddColumnSQL(Field{Table: "user", Name: "email", Type: "TEXT", Nullable: true}), want: `ALTER TABLE "user" ADD COLUMN "email" TEXT;`},
+		{name: "drop column", got: d.DropColumnSQL(bio), want: `ALTER TABLE "user" DROP COLUMN "bio";`},
+		{name: "rename column", got: d.ModifyColumnSQL(bio, renamed), want: `ALTER TABLE "user" RENAME COLUMN "bio" TO "profile";`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(tt.got, "\n"); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
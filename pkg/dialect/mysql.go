@@ -1,13 +1,27 @@
 package dialect
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/naoina/go-stringutil"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
-var _ PrimaryKeyModifier = &MySQL{}
+var (
+	_ PrimaryKeyModifier = &MySQL{}
+	_ ForeignKeyModifier = &MySQL{}
+	_ Inspector          = &MySQL{}
+	_ Migrator           = &MySQL{}
+	_ RecordQuerier      = &MySQL{}
+	_ RelationQuerier    = &MySQL{}
+)
+
+// mysqlLockTimeout is how many seconds Lock waits for another session to release the lock.
+const mysqlLockTimeout = 600
 
 var (
 	mysqlColumnTypes = []*ColumnType{
@@ -53,7 +67,7 @@ var (
 			GoNullableTypes: []string{"*float64", "sql.NullFloat64"},
 		},
 		{
-			Types:           []string{"DATETIME"},
+			Types:           []string{"DATETIME", "TIMESTAMP"},
 			GoTypes:         []string{"time.Time"},
 			GoNullableTypes: []string{"*time.Time", "mysql.NullTime", "gorp.NullTime"},
 		},
@@ -120,7 +134,7 @@ func (d *MySQL) IsNullable(name string) bool {
 
 func (d *MySQL) ImportPackage(schema ColumnSchema) string {
 	switch schema.DataType() {
-	case "datetime":
+	case "datetime", "timestamp":
 		return "time"
 	}
 	return ""
@@ -151,9 +165,12 @@ func (d *MySQL) CreateTableSQL(table Table) []string {
 		columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(pkColumns, ", ")))
 	}
 	if len(table.ForeignKeys) > 0 {
-		for name, reference := range table.ForeignKeys {
+		for _, name := range ForeignKeyNames(table) {
+			reference := table.ForeignKeys[name]
+			// Constraints are named like PostgreSQL does, so that DropForeignKeySQL can find them.
 			columns = append(columns,
-				fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s(%s)",
+				fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(%s)",
+					d.Quote(ForeignKeyName(table.Name, stringutil.ToSnakeCase(name))),
 					d.Quote(stringutil.ToSnakeCase(name)),
 					d.Quote(stringutil.ToSnakeCase(reference.Table)),
 					d.Quote(stringutil.ToSnakeCase(reference.Column))))
@@ -162,85 +179,84 @@ func (d *MySQL) CreateTableSQL(table Table) []string {
 
 	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n"+
 		"  %s\n"+
-		");", d.Quote(table.Name), strings.Join(columns, ",\n  "))
+		")", d.Quote(table.Name), strings.Join(columns, ",\n  "))
 	if table.Option != "" {
 		query += " " + table.Option
 	}
-	return []string{query}
+	return []string{query + ";"}
 }
 
 func (d *MySQL) DropTableSQL(table Table) []string {
-	query := fmt.Sprintf("DROP TABLE IF EXISTS %s;", d.Quote(table.Name))
-	if table.Option != "" {
-		query += " " + table.Option
-	}
-	return []string{query}
+	return []string{fmt.Sprintf("DROP TABLE IF EXISTS %s;", d.Quote(table.Name))}
 }
 
 func (d *MySQL) FindAllSQL(table Table) []string {
-	columns := make([]string, len(table.Fields))
-	for i, f := range table.Fields {
-		columns[i] = d.Quote(f.Name)
-	}
-	columns = append(columns, "`created_at`")
-	columns = append(columns, "`updated_at`")
-	query := fmt.Sprintf("SELECT %s FROM %s;", strings.Join(columns, ", "), d.Quote(table.Name))
-	return []string{query}
+	return []string{d.records().findAllSQL(table)}
 }
 
 func (d *MySQL) FindSQL(table Table) []string {
-	columns := make([]string, len(table.Fields))
-	for i, f := range table.Fields {
-		columns[i] = d.Quote(f.Name)
-	}
-	columns = append(columns, "`created_at`")
-	columns = append(columns, "`updated_at`")
-	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?;", strings.Join(columns, ", "), d.Quote(table.Name), columns[0])
-	return []string{query}
+	return []string{d.records().findSQL(table)}
 }
 
 func (d *MySQL) CreateSQL(table Table) []string {
-	columns := make([]string, len(table.Fields))
-	values := make([]string, len(table.Fields))
-	for i, f := range table.Fields {
-		columns[i] = d.Quote(f.Name)
-		values[i] = "?"
-	}
-	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", d.Quote(table.Name), strings.Join(columns, ", "), strings.Join(values, ", "))
-	return []string{query}
+	return []string{d.records().createSQL(table)}
 }
 
 func (d *MySQL) DeleteSQL(table Table) []string {
-	columns := make([]string, len(table.Fields))
-	for i, f := range table.Fields {
-		columns[i] = d.Quote(f.Name)
-	}
-	query := fmt.Sprintf("DELETE FROM %s WHERE %s = ?;", d.Quote(table.Name), d.Quote(table.Fields[0].Name))
-	return []string{query}
+	return []string{d.records().deleteSQL(table)}
 }
 
 func (d *MySQL) UpdateSQL(table Table) []string {
-	set := make([]string, len(table.Fields))
-	for i, f := range table.Fields {
-		if i == 0 {
-			continue
-		}
-		set[i] = fmt.Sprintf("%s = ?", d.Quote(f.Name))
-	}
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?;", d.Quote(table.Name), strings.Join(set, " "), d.Quote(table.Fields[0].Name))
-	return []string{query}
+	return []string{d.records().updateSQL(table)}
+}
+
+func (d *MySQL) UpsertSQL(table Table) []string {
+	r := d.records()
+	columns, values, set := r.upsertColumns(table, func(column string) string {
+		return fmt.Sprintf("VALUES(%s)", column)
+	})
+	return []string{fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s;",
+		d.Quote(table.Name), strings.Join(columns, ", "), strings.Join(values, ", "), strings.Join(set, ", "))}
+}
+
+func (d *MySQL) BatchCreateSQL(table Table, rows int) []string {
+	return []string{d.records().batchCreateSQL(table, rows)}
+}
+
+func (d *MySQL) PaginateSQL(table Table) []string {
+	return []string{d.records().paginateSQL(table)}
+}
+
+func (d *MySQL) FindByIndexSQL(table Table, index Index) []string {
+	return []string{d.records().findByIndexSQL(table, index)}
+}
+
+func (d *MySQL) ParentSQL(table Table, column string, parent Table, parentColumn string) []string {
+	return []string{d.records().parentSQL(table, column, parent, parentColumn)}
+}
+
+func (d *MySQL) ChildrenSQL(table Table, column string) []string {
+	return []string{d.records().childrenSQL(table, column)}
+}
+
+func (d *MySQL) ThroughSQL(table Table, column string, through Table, from string, to string) []string {
+	return []string{d.records().throughSQL(table, column, through, from, to)}
+}
+
+func (d *MySQL) records() recordSQL {
+	return recordSQL{quote: d.Quote, placeholder: questionMark, defaultValues: "() VALUES ()"}
 }
 
 func (d *MySQL) AddColumnSQL(field Field) []string {
-	return []string{fmt.Sprintf("ALTER TABLE %s ADD %s", d.Quote(field.Table), d.columnSQL(field))}
+	return []string{fmt.Sprintf("ALTER TABLE %s ADD %s;", d.Quote(field.Table), d.columnSQL(field))}
 }
 
 func (d *MySQL) DropColumnSQL(field Field) []string {
-	return []string{fmt.Sprintf("ALTER TABLE %s DROP %s", d.Quote(field.Table), d.Quote(field.Name))}
+	return []string{fmt.Sprintf("ALTER TABLE %s DROP %s;", d.Quote(field.Table), d.Quote(field.Name))}
 }
 
 func (d *MySQL) ModifyColumnSQL(oldField, newField Field) []string {
-	return []string{fmt.Sprintf("ALTER TABLE %s CHANGE %s %s", d.Quote(newField.Table), d.Quote(oldField.Name), d.columnSQL(newField))}
+	return []string{fmt.Sprintf("ALTER TABLE %s CHANGE %s %s;", d.Quote(newField.Table), d.Quote(oldField.Name), d.columnSQL(newField))}
 }
 
 func (d *MySQL) ModifyPrimaryKeySQL(oldPrimaryKeys, newPrimaryKeys []Field) []string {
@@ -254,12 +270,23 @@ func (d *MySQL) ModifyPrimaryKeySQL(oldPrimaryKeys, newPrimaryKeys []Field) []st
 	if len(oldPrimaryKeys) > 0 {
 		specs = append(specs, "DROP PRIMARY KEY")
 	}
-	pkColumns := make([]string, len(newPrimaryKeys))
-	for i, pk := range newPrimaryKeys {
-		pkColumns[i] = d.Quote(pk.Name)
+	if len(newPrimaryKeys) > 0 {
+		pkColumns := make([]string, len(newPrimaryKeys))
+		for i, pk := range newPrimaryKeys {
+			pkColumns[i] = d.Quote(pk.Name)
+		}
+		specs = append(specs, fmt.Sprintf("ADD PRIMARY KEY (%s)", strings.Join(pkColumns, ", ")))
 	}
-	specs = append(specs, fmt.Sprintf("ADD PRIMARY KEY (%s)", strings.Join(pkColumns, ", ")))
-	return []string{fmt.Sprintf("ALTER TABLE %s %s", d.Quote(tableName), strings.Join(specs, ", "))}
+	return []string{fmt.Sprintf("ALTER TABLE %s %s;", d.Quote(tableName), strings.Join(specs, ", "))}
+}
+
+func (d *MySQL) AddForeignKeySQL(table string, column string, reference ForeignKey) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(%s);",
+		d.Quote(table), d.Quote(ForeignKeyName(table, column)), d.Quote(column), d.Quote(reference.Table), d.Quote(reference.Column))}
+}
+
+func (d *MySQL) DropForeignKeySQL(table string, column string) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;", d.Quote(table), d.Quote(ForeignKeyName(table, column)))}
 }
 
 func (d *MySQL) CreateIndexSQL(index Index) []string {
@@ -271,13 +298,13 @@ func (d *MySQL) CreateIndexSQL(index Index) []string {
 	tableName := d.Quote(index.Table)
 	column := strings.Join(columns, ",")
 	if index.Unique {
-		return []string{fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s)", indexName, tableName, column)}
+		return []string{fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s);", indexName, tableName, column)}
 	}
-	return []string{fmt.Sprintf("CREATE INDEX %s ON %s (%s)", indexName, tableName, column)}
+	return []string{fmt.Sprintf("CREATE INDEX %s ON %s (%s);", indexName, tableName, column)}
 }
 
 func (d *MySQL) DropIndexSQL(index Index) []string {
-	return []string{fmt.Sprintf("DROP INDEX %s ON %s", d.Quote(index.Name), d.Quote(index.Table))}
+	return []string{fmt.Sprintf("DROP INDEX %s ON %s;", d.Quote(index.Name), d.Quote(index.Table))}
 }
 
 func (d *MySQL) columnSQL(f Field) string {
@@ -345,6 +372,71 @@ type mysqlVersion struct {
 	Name  string
 }
 
+var mysqlVersionPattern = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)`)
+
+// parseMySQLVersion parses the result of VERSION() like "8.0.23", "5.7.33-log" or "5.5.5-10.3.27-MariaDB-1:10.3.27+maria~focal".
+func parseMySQLVersion(s string) (*mysqlVersion, error) {
+	v := &mysqlVersion{Name: "MySQL"}
+	if strings.Contains(s, "MariaDB") {
+		v.Name = "MariaDB"
+		// MariaDB 10.x reports a fake 5.5.5- prefix for compatibility with old replication clients.
+		s = strings.TrimPrefix(s, "5.5.5-")
+	}
+	m := mysqlVersionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("auto-table: unknown version format: %s", s)
+	}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	return v, nil
+}
+
+func (v *mysqlVersion) atLeast(major, minor, patch int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	if v.Minor != minor {
+		return v.Minor > minor
+	}
+	return v.Patch >= patch
+}
+
+// TransactionalDDL returns false because MySQL commits implicitly before and after every DDL statement.
+func (d *MySQL) TransactionalDDL() bool {
+	return false
+}
+
+func (d *MySQL) Begin(conn *sql.Conn) (Transactioner, error) {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &mysqlTransaction{tx: tx}, nil
+}
+
+func (d *MySQL) Lock(conn *sql.Conn, name string) error {
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(context.Background(), "SELECT GET_LOCK(?, ?)", name, mysqlLockTimeout).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return fmt.Errorf("auto-table: could not get lock %s within %d seconds", name, mysqlLockTimeout)
+	}
+	return nil
+}
+
+func (d *MySQL) Unlock(conn *sql.Conn, name string) error {
+	_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", name)
+	return err
+}
+
+func (d *MySQL) HasTable(conn *sql.Conn, name string) (bool, error) {
+	var n int
+	err := conn.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", name).Scan(&n)
+	return n > 0, err
+}
+
 type mysqlTransaction struct {
 	tx *sql.Tx
 }
@@ -401,6 +493,7 @@ type mysqlColumnSchema struct {
 	columnComment          string
 	nonUnique              int64
 	indexName              string
+	seqInIndex             int64
 
 	version *mysqlVersion
 }
@@ -453,7 +546,7 @@ func (schema *mysqlColumnSchema) Default() (string, bool) {
 	def := schema.columnDefault.String
 	v := schema.version
 	// See https://mariadb.com/kb/en/library/information-schema-columns-table/
-	if v.Name == "MariaDB" && v.Major >= 10 && v.Minor >= 2 && v.Patch >= 7 {
+	if v.Name == "MariaDB" && v.atLeast(10, 2, 7) {
 		// unquote string
 		if len(def) > 0 && def[0] == '\'' {
 			def = def[1:]
@@ -485,7 +578,15 @@ func (schema *mysqlColumnSchema) Extra() (string, bool) {
 	// Trim parenthesis from like "on update current_timestamp()".
 	extra := strings.TrimSuffix(schema.extra, "()")
 	extra = strings.ToUpper(extra)
-	return extra, true
+	// MySQL 8 marks columns with expression defaults by DEFAULT_GENERATED, which is no part of a column definition.
+	var words []string
+	for _, w := range strings.Fields(extra) {
+		if w != "DEFAULT_GENERATED" {
+			words = append(words, w)
+		}
+	}
+	extra = strings.Join(words, " ")
+	return extra, extra != ""
 }
 
 func (schema *mysqlColumnSchema) Comment() (string, bool) {
@@ -495,3 +596,221 @@ func (schema *mysqlColumnSchema) Comment() (string, bool) {
 func (schema *mysqlColumnSchema) isUnsigned() bool {
 	return strings.Contains(schema.columnType, "unsigned")
 }
+
+// Inspect reads the tables of the database selected by the connection from information_schema.
+func (d *MySQL) Inspect(db *sql.DB) ([]Table, error) {
+	var rawVersion string
+	if err := db.QueryRow("SELECT VERSION()").Scan(&rawVersion); err != nil {
+		return nil, err
+	}
+	version, err := parseMySQLVersion(rawVersion)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := d.inspectColumns(db, version)
+	if err != nil {
+		return nil, err
+	}
+	fks, fkNames, err := d.inspectForeignKeys(db)
+	if err != nil {
+		return nil, err
+	}
+	return d.makeTables(schemas, fks, fkNames), nil
+}
+
+// inspectColumns reads one schema per column and index the column belongs to.
+func (d *MySQL) inspectColumns(db *sql.DB, version *mysqlVersion) ([]*mysqlColumnSchema, error) {
+	rows, err := db.Query(`SELECT
+  c.TABLE_NAME,
+  c.COLUMN_NAME,
+  c.ORDINAL_POSITION,
+  c.COLUMN_DEFAULT,
+  c.IS_NULLABLE,
+  c.DATA_TYPE,
+  c.CHARACTER_MAXIMUM_LENGTH,
+  c.CHARACTER_OCTET_LENGTH,
+  c.NUMERIC_PRECISION,
+  c.NUMERIC_SCALE,
+  c.DATETIME_PRECISION,
+  c.COLUMN_TYPE,
+  c.COLUMN_KEY,
+  c.EXTRA,
+  c.COLUMN_COMMENT,
+  s.NON_UNIQUE,
+  s.INDEX_NAME,
+  s.SEQ_IN_INDEX
+FROM information_schema.COLUMNS c
+LEFT JOIN information_schema.STATISTICS s
+  ON c.TABLE_SCHEMA = s.TABLE_SCHEMA AND c.TABLE_NAME = s.TABLE_NAME AND c.COLUMN_NAME = s.COLUMN_NAME
+WHERE c.TABLE_SCHEMA = DATABASE()
+ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION, s.INDEX_NAME, s.SEQ_IN_INDEX`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schemas []*mysqlColumnSchema
+	for rows.Next() {
+		schema := &mysqlColumnSchema{version: version}
+		var nonUnique, seqInIndex sql.NullInt64
+		var indexName sql.NullString
+		if err := rows.Scan(
+			&schema.tableName,
+			&schema.columnName,
+			&schema.ordinalPosition,
+			&schema.columnDefault,
+			&schema.isNullable,
+			&schema.dataType,
+			&schema.characterMaximumLength,
+			&schema.characterOctetLength,
+			&schema.numericPrecision,
+			&schema.numericScale,
+			&schema.datetimePrecision,
+			&schema.columnType,
+			&schema.columnKey,
+			&schema.extra,
+			&schema.columnComment,
+			&nonUnique,
+			&indexName,
+			&seqInIndex,
+		); err != nil {
+			return nil, err
+		}
+		schema.nonUnique = nonUnique.Int64
+		schema.indexName = indexName.String
+		schema.seqInIndex = seqInIndex.Int64
+		schemas = append(schemas, schema)
+	}
+	return schemas, rows.Err()
+}
+
+// inspectForeignKeys reads the foreign keys by table and column, and the names of their constraints by table.
+func (d *MySQL) inspectForeignKeys(db *sql.DB) (map[string]map[string]ForeignKey, map[string]map[string]struct{}, error) {
+	rows, err := db.Query(`SELECT TABLE_NAME, COLUMN_NAME, CONSTRAINT_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+FROM information_schema.KEY_COLUMN_USAGE
+WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL
+ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	fks := map[string]map[string]ForeignKey{}   // map[tableName]map[columnName]reference
+	fkNames := map[string]map[string]struct{}{} // map[tableName]constraintNames
+	for rows.Next() {
+		var table, column, constraint string
+		var reference ForeignKey
+		if err := rows.Scan(&table, &column, &constraint, &reference.Table, &reference.Column); err != nil {
+			return nil, nil, err
+		}
+		if fks[table] == nil {
+			fks[table] = map[string]ForeignKey{}
+			fkNames[table] = map[string]struct{}{}
+		}
+		fks[table][column] = reference
+		fkNames[table][constraint] = struct{}{}
+	}
+	return fks, fkNames, rows.Err()
+}
+
+func (d *MySQL) makeTables(schemas []*mysqlColumnSchema, fks map[string]map[string]ForeignKey, fkNames map[string]map[string]struct{}) []Table {
+	type indexColumn struct {
+		seq    int64
+		column string
+	}
+	var tables []Table
+	tableMap := map[string]int{}                          // map[tableName]position in tables
+	seen := map[string]map[string]struct{}{}              // map[tableName]columnNames
+	pkColumns := map[string][]indexColumn{}               // map[tableName]columns
+	indexInfos := map[string]map[string]*mysqlIndexInfo{} // map[tableName]map[indexName]info
+	indexColumns := map[string]map[string][]indexColumn{} // map[tableName]map[indexName]columns
+	indexNames := map[string][]string{}                   // map[tableName]indexNames in order of appearance
+
+	for _, schema := range schemas {
+		name := schema.TableName()
+		if _, ok := tableMap[name]; !ok {
+			tableMap[name] = len(tables)
+			tables = append(tables, Table{Name: name, ForeignKeys: fks[name]})
+			seen[name] = map[string]struct{}{}
+			indexInfos[name] = map[string]*mysqlIndexInfo{}
+			indexColumns[name] = map[string][]indexColumn{}
+		}
+		t := &tables[tableMap[name]]
+
+		if _, ok := seen[name][schema.ColumnName()]; !ok {
+			seen[name][schema.ColumnName()] = struct{}{}
+			t.Fields = append(t.Fields, d.makeField(schema))
+		}
+		if schema.IsPrimaryKey() {
+			pkColumns[name] = append(pkColumns[name], indexColumn{seq: schema.seqInIndex, column: schema.ColumnName()})
+			continue
+		}
+		indexName, unique, ok := schema.Index()
+		if !ok {
+			continue
+		}
+		// Indexes InnoDB creates for foreign key constraints are not declared by the user.
+		if _, ok := fkNames[name][indexName]; ok {
+			continue
+		}
+		if _, ok := indexInfos[name][indexName]; !ok {
+			nonUnique := int64(1)
+			if unique {
+				nonUnique = 0
+			}
+			indexInfos[name][indexName] = &mysqlIndexInfo{NonUnique: nonUnique, IndexName: indexName}
+			indexNames[name] = append(indexNames[name], indexName)
+		}
+		indexColumns[name][indexName] = append(indexColumns[name][indexName], indexColumn{seq: schema.seqInIndex, column: schema.ColumnName()})
+	}
+
+	sortColumns := func(columns []indexColumn) []string {
+		sort.SliceStable(columns, func(i, j int) bool { return columns[i].seq < columns[j].seq })
+		names := make([]string, len(columns))
+		for i, c := range columns {
+			names[i] = c.column
+		}
+		return names
+	}
+	for i := range tables {
+		t := &tables[i]
+		if pks, ok := pkColumns[t.Name]; ok {
+			t.PrimaryKeys = sortColumns(pks)
+		}
+		for _, indexName := range indexNames[t.Name] {
+			info := indexInfos[t.Name][indexName]
+			t.Indexes = append(t.Indexes, Index{
+				Table:   t.Name,
+				Name:    info.IndexName,
+				Columns: sortColumns(indexColumns[t.Name][indexName]),
+				Unique:  info.NonUnique == 0,
+			})
+		}
+	}
+	return tables
+}
+
+func (d *MySQL) makeField(schema *mysqlColumnSchema) Field {
+	typ := schema.ColumnType()
+	// Keep the case of quoted values like in enum('a','b').
+	if !strings.ContainsRune(typ, '\'') {
+		typ = strings.ToUpper(typ)
+	}
+	f := Field{
+		Table:         schema.TableName(),
+		Name:          schema.ColumnName(),
+		Type:          typ,
+		AutoIncrement: schema.IsAutoIncrement(),
+		Nullable:      schema.IsNullable(),
+	}
+	if def, ok := schema.Default(); ok {
+		f.Default = def
+	}
+	if extra, ok := schema.Extra(); ok {
+		f.Extra = extra
+	}
+	if comment, ok := schema.Comment(); ok {
+		f.Comment = comment
+	}
+	return f
+}
@@ -0,0 +1,138 @@
+package dialect
+
+import (
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"reflect"
+	"testing"
+)
+
+var mysqlInspectColumns = []string{
+	"TABLE_NAME", "COLUMN_NAME", "ORDINAL_POSITION", "COLUMN_DEFAULT", "IS_NULLABLE", "DATA_TYPE",
+	"CHARACTER_MAXIMUM_LENGTH", "CHARACTER_OCTET_LENGTH", "NUMERIC_PRECISION", "NUMERIC_SCALE", "DATETIME_PRECISION",
+	"COLUMN_TYPE", "COLUMN_KEY", "EXTRA", "COLUMN_COMMENT", "NON_UNIQUE", "INDEX_NAME", "SEQ_IN_INDEX",
+}
+
+// mysqlColumnRow is a row of the COLUMNS and STATISTICS query, with nil for NULL.
+func mysqlColumnRow(table, column string, position int64, def driver.Value, nullable, dataType, columnType, key, extra, comment string, nonUnique, indexName, seq driver.Value) []driver.Value {
+	return []driver.Value{table, column, position, def, nullable, dataType, nil, nil, nil, nil, nil, columnType, key, extra, comment, nonUnique, indexName, seq}
+}
+
+func TestMySQLInspect(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		columns [][]driver.Value
+		fks     [][]driver.Value
+		want    []Table
+	}{
+		{
+			name:    "columns, keys and indexes",
+			version: "8.0.33",
+			columns: [][]driver.Value{
+				mysqlColumnRow("micropost", "id", 1, nil, "NO", "bigint", "bigint(20)", "PRI", "auto_increment", "", 0, "PRIMARY", 1),
+				mysqlColumnRow("micropost", "author_id", 2, nil, "NO", "bigint", "bigint(20)", "MUL", "", "", 1, "micropost_author_id_content_idx", 1),
+				mysqlColumnRow("micropost", "author_id", 2, nil, "NO", "bigint", "bigint(20)", "MUL", "", "", 1, "micropost_author_id_fkey", 1),
+				mysqlColumnRow("micropost", "content", 3, nil, "NO", "varchar", "varchar(140)", "", "", "", 1, "micropost_author_id_content_idx", 2),
+				mysqlColumnRow("micropost", "created_at", 4, "CURRENT_TIMESTAMP", "YES", "datetime", "datetime", "", "DEFAULT_GENERATED", "", nil, nil, nil),
+				mysqlColumnRow("micropost", "updated_at", 5, "CURRENT_TIMESTAMP", "YES", "datetime", "datetime", "", "DEFAULT_GENERATED on update CURRENT_TIMESTAMP", "", nil, nil, nil),
+				mysqlColumnRow("micropost_tag", "micropost_id", 1, nil, "NO", "bigint", "bigint(20)", "PRI", "", "", 0, "PRIMARY", 1),
+				mysqlColumnRow("micropost_tag", "tag_id", 2, nil, "NO", "int", "int(10) unsigned", "PRI", "", "", 0, "PRIMARY", 2),
+				mysqlColumnRow("user", "id", 1, nil, "NO", "bigint", "bigint(20)", "PRI", "auto_increment", "", 0, "PRIMARY", 1),
+				mysqlColumnRow("user", "name", 2, "guest", "NO", "varchar", "varchar(255)", "UNI", "", "login name", 0, "user_name_idx", 1),
+				mysqlColumnRow("user", "bio", 3, nil, "YES", "text", "text", "", "", "", nil, nil, nil),
+				mysqlColumnRow("user", "active", 4, "1", "NO", "tinyint", "tinyint(1)", "", "", "", nil, nil, nil),
+				mysqlColumnRow("user", "role", 5, "member", "NO", "enum", "enum('admin','member')", "", "", "", nil, nil, nil),
+			},
+			fks: [][]driver.Value{
+				{"micropost", "author_id", "micropost_author_id_fkey", "user", "id"},
+			},
+			want: []Table{
+				{
+					Name: "micropost",
+					Fields: []Field{
+						{Table: "micropost", Name: "id", Type: "BIGINT", AutoIncrement: true},
+						{Table: "micropost", Name: "author_id", Type: "BIGINT"},
+						{Table: "micropost", Name: "content", Type: "VARCHAR(140)"},
+						{Table: "micropost", Name: "created_at", Type: "DATETIME", Default: "CURRENT_TIMESTAMP", Nullable: true},
+						{Table: "micropost", Name: "updated_at", Type: "DATETIME", Default: "CURRENT_TIMESTAMP", Extra: "ON UPDATE CURRENT_TIMESTAMP", Nullable: true},
+					},
+					PrimaryKeys: []string{"id"},
+					ForeignKeys: map[string]ForeignKey{"author_id": {Table: "user", Column: "id"}},
+					Indexes:     []Index{{Table: "micropost", Name: "micropost_author_id_content_idx", Columns: []string{"author_id", "content"}}},
+				},
+				{
+					Name: "micropost_tag",
+					Fields: []Field{
+						{Table: "micropost_tag", Name: "micropost_id", Type: "BIGINT"},
+						{Table: "micropost_tag", Name: "tag_id", Type: "INT UNSIGNED"},
+					},
+					PrimaryKeys: []string{"micropost_id", "tag_id"},
+				},
+				{
+					Name: "user",
+					Fields: []Field{
+						{Table: "user", Name: "id", Type: "BIGINT", AutoIncrement: true},
+						{Table: "user", Name: "name", Type: "VARCHAR(255)", Default: "guest", Comment: "login name"},
+						{Table: "user", Name: "bio", Type: "TEXT", Nullable: true},
+						{Table: "user", Name: "active", Type: "TINYINT(1)", Default: "1"},
+						{Table: "user", Name: "role", Type: "enum('admin','member')", Default: "member"},
+					},
+					PrimaryKeys: []string{"id"},
+					Indexes:     []Index{{Table: "user", Name: "user_name_idx", Columns: []string{"name"}, Unique: true}},
+				},
+			},
+		},
+		{
+			name:    "quoted defaults of MariaDB",
+			version: "5.5.5-10.6.12-MariaDB-log",
+			columns: [][]driver.Value{
+				mysqlColumnRow("user", "name", 1, "'O''Brien'", "NO", "varchar", "varchar(255)", "", "", "", nil, nil, nil),
+				mysqlColumnRow("user", "bio", 2, "NULL", "YES", "text", "text", "", "", "", nil, nil, nil),
+				mysqlColumnRow("user", "updated_at", 3, "current_timestamp()", "NO", "datetime", "datetime", "", "on update current_timestamp()", "", nil, nil, nil),
+			},
+			want: []Table{
+				{
+					Name: "user",
+					Fields: []Field{
+						{Table: "user", Name: "name", Type: "VARCHAR(255)", Default: "O'Brien"},
+						{Table: "user", Name: "bio", Type: "TEXT", Nullable: true},
+						{Table: "user", Name: "updated_at", Type: "DATETIME", Default: "current_timestamp", Extra: "ON UPDATE CURRENT_TIMESTAMP"},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery(`SELECT VERSION\(\)`).WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow(tt.version))
+			columns := sqlmock.NewRows(mysqlInspectColumns)
+			for _, row := range tt.columns {
+				columns.AddRow(row...)
+			}
+			mock.ExpectQuery(`FROM information_schema\.COLUMNS`).WillReturnRows(columns)
+			fks := sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "CONSTRAINT_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME"})
+			for _, row := range tt.fks {
+				fks.AddRow(row...)
+			}
+			mock.ExpectQuery(`FROM information_schema\.KEY_COLUMN_USAGE`).WillReturnRows(fks)
+
+			got, err := NewMySQL().(Inspector).Inspect(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Inspect() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,493 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/naoina/go-stringutil"
+	"hash/fnv"
+	"strings"
+)
+
+var (
+	_ PrimaryKeyModifier = &Postgres{}
+	_ ForeignKeyModifier = &Postgres{}
+	_ Migrator           = &Postgres{}
+	_ RecordQuerier      = &Postgres{}
+	_ RelationQuerier    = &Postgres{}
+)
+
+var (
+	postgresColumnTypes = []*ColumnType{
+		{
+			Types:           []string{"VARCHAR", "TEXT", "CHAR", "CHARACTER VARYING", "CHARACTER"},
+			GoTypes:         []string{"string"},
+			GoNullableTypes: []string{"*string", "sql.NullString"},
+		},
+		{
+			Types:           []string{"BYTEA"},
+			GoTypes:         []string{"[]byte"},
+			GoNullableTypes: []string{"[]byte"},
+		},
+		{
+			Types:           []string{"INTEGER", "INT", "INT4", "SERIAL"},
+			GoTypes:         []string{"int32", "int", "uint16"},
+			GoNullableTypes: []string{"*int32", "sql.NullInt32"},
+		},
+		{
+			Types:           []string{"SMALLINT", "INT2", "SMALLSERIAL"},
+			GoTypes:         []string{"int16", "int8", "uint8"},
+			GoNullableTypes: []string{"*int16", "sql.NullInt16"},
+		},
+		{
+			Types:           []string{"BIGINT", "INT8", "BIGSERIAL"},
+			GoTypes:         []string{"int64", "uint32", "uint", "uint64"},
+			GoNullableTypes: []string{"*int64", "sql.NullInt64"},
+		},
+		{
+			Types:           []string{"BOOLEAN", "BOOL"},
+			GoTypes:         []string{"bool"},
+			GoNullableTypes: []string{"*bool", "sql.NullBool"},
+		},
+		{
+			Types:           []string{"DOUBLE PRECISION", "FLOAT8", "NUMERIC", "DECIMAL"},
+			GoTypes:         []string{"float64"},
+			GoNullableTypes: []string{"*float64", "sql.NullFloat64"},
+		},
+		{
+			Types:   []string{"REAL", "FLOAT4"},
+			GoTypes: []string{"float32"},
+		},
+		{
+			Types:           []string{"TIMESTAMP", "TIMESTAMPTZ", "DATE"},
+			GoTypes:         []string{"time.Time"},
+			GoNullableTypes: []string{"*time.Time", "sql.NullTime", "pq.NullTime"},
+		},
+	}
+
+	// postgresSerialTypes maps integer column types to the serial pseudo-type used for AutoIncrement columns.
+	postgresSerialTypes = map[string]string{
+		"SMALLINT": "SMALLSERIAL",
+		"INTEGER":  "SERIAL",
+		"BIGINT":   "BIGSERIAL",
+	}
+)
+
+type Postgres struct {
+	columnTypeMap   map[string]*ColumnType
+	nullableTypeMap map[string]struct{}
+}
+
+func NewPostgres() Dialect {
+	d := &Postgres{
+		columnTypeMap:   map[string]*ColumnType{},
+		nullableTypeMap: map[string]struct{}{},
+	}
+
+	for _, types := range [][]*ColumnType{postgresColumnTypes} {
+		for _, t := range types {
+			for _, tt := range t.allGoTypes() {
+				d.columnTypeMap[tt] = t
+			}
+			for _, tt := range t.filteredNullableGoTypes() {
+				d.nullableTypeMap[tt] = struct{}{}
+			}
+		}
+	}
+	return d
+}
+
+func (d *Postgres) ColumnType(name string) string {
+	if t, ok := d.columnTypeMap[name]; ok {
+		// PostgreSQL has no unsigned integers, so unsigned Go types are mapped to a wider signed type by the table.
+		name, _, _, _ = t.findType(name)
+	}
+	name = d.defaultColumnType(name)
+	return strings.ToUpper(name)
+}
+
+func (d *Postgres) GoType(name string, nullable bool) string {
+	name = strings.ToUpper(name)
+	for _, t := range postgresColumnTypes {
+		if typ, found := t.findGoType(name, nullable, false); found {
+			return typ
+		}
+	}
+	if strings.IndexByte(name, '(') >= 0 {
+		return d.GoType(strings.TrimSpace(trimParens(name)), nullable)
+	}
+	if strings.HasSuffix(name, " WITH TIME ZONE") || strings.HasSuffix(name, " WITHOUT TIME ZONE") {
+		return d.GoType(name[:strings.Index(name, " WITH")], nullable)
+	}
+	return "interface{}"
+}
+
+func (d *Postgres) IsNullable(name string) bool {
+	_, ok := d.nullableTypeMap[name]
+	return ok
+}
+
+func (d *Postgres) ImportPackage(schema ColumnSchema) string {
+	switch schema.DataType() {
+	case "timestamp", "timestamptz", "date",
+		"timestamp with time zone", "timestamp without time zone":
+		return "time"
+	}
+	return ""
+}
+
+func (d *Postgres) Quote(s string) string {
+	return fmt.Sprintf(`"%s"`, strings.Replace(s, `"`, `""`, -1))
+}
+
+func (d *Postgres) QuoteString(s string) string {
+	return fmt.Sprintf("'%s'", strings.Replace(s, "'", "''", -1))
+}
+
+func (d *Postgres) CreateTableSQL(table Table) []string {
+	columns := make([]string, len(table.Fields))
+	for i, f := range table.Fields {
+		columns[i] = d.columnSQL(f)
+	}
+	columns = append(columns, fmt.Sprintf("%s TIMESTAMP DEFAULT CURRENT_TIMESTAMP", d.Quote("created_at")))
+	columns = append(columns, fmt.Sprintf("%s TIMESTAMP DEFAULT CURRENT_TIMESTAMP", d.Quote("updated_at")))
+
+	if len(table.PrimaryKeys) > 0 {
+		pkColumns := make([]string, len(table.PrimaryKeys))
+		for i, pk := range table.PrimaryKeys {
+			pkColumns[i] = d.Quote(pk)
+		}
+		columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(pkColumns, ", ")))
+	}
+	if len(table.ForeignKeys) > 0 {
+		for _, name := range ForeignKeyNames(table) {
+			reference := table.ForeignKeys[name]
+			columns = append(columns,
+				fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s(%s)",
+					d.Quote(stringutil.ToSnakeCase(name)),
+					d.Quote(stringutil.ToSnakeCase(reference.Table)),
+					d.Quote(stringutil.ToSnakeCase(reference.Column))))
+		}
+	}
+
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n"+
+		"  %s\n"+
+		")", d.Quote(table.Name), strings.Join(columns, ",\n  "))
+	if table.Option != "" {
+		query += " " + table.Option
+	}
+	queries := []string{query + ";"}
+
+	// PostgreSQL has no inline column comment, so comments are attached after the table exists.
+	for _, f := range table.Fields {
+		if f.Comment != "" {
+			queries = append(queries, d.commentSQL(table.Name, f))
+		}
+	}
+	return queries
+}
+
+func (d *Postgres) DropTableSQL(table Table) []string {
+	return []string{fmt.Sprintf("DROP TABLE IF EXISTS %s;", d.Quote(table.Name))}
+}
+
+func (d *Postgres) FindAllSQL(table Table) []string {
+	return []string{d.records().findAllSQL(table)}
+}
+
+func (d *Postgres) FindSQL(table Table) []string {
+	return []string{d.records().findSQL(table)}
+}
+
+func (d *Postgres) CreateSQL(table Table) []string {
+	return []string{d.records().createSQL(table)}
+}
+
+func (d *Postgres) DeleteSQL(table Table) []string {
+	return []string{d.records().deleteSQL(table)}
+}
+
+func (d *Postgres) UpdateSQL(table Table) []string {
+	return []string{d.records().updateSQL(table)}
+}
+
+func (d *Postgres) UpsertSQL(table Table) []string {
+	return []string{d.records().onConflictSQL(table)}
+}
+
+func (d *Postgres) BatchCreateSQL(table Table, rows int) []string {
+	return []string{d.records().batchCreateSQL(table, rows)}
+}
+
+func (d *Postgres) PaginateSQL(table Table) []string {
+	return []string{d.records().paginateSQL(table)}
+}
+
+func (d *Postgres) FindByIndexSQL(table Table, index Index) []string {
+	return []string{d.records().findByIndexSQL(table, index)}
+}
+
+func (d *Postgres) ParentSQL(table Table, column string, parent Table, parentColumn string) []string {
+	return []string{d.records().parentSQL(table, column, parent, parentColumn)}
+}
+
+func (d *Postgres) ChildrenSQL(table Table, column string) []string {
+	return []string{d.records().childrenSQL(table, column)}
+}
+
+func (d *Postgres) ThroughSQL(table Table, column string, through Table, from string, to string) []string {
+	return []string{d.records().throughSQL(table, column, through, from, to)}
+}
+
+func (d *Postgres) records() recordSQL {
+	return recordSQL{quote: d.Quote, placeholder: d.placeholder, defaultValues: "DEFAULT VALUES"}
+}
+
+// AddColumnSQL fills the existing rows with the zero value of a NOT NULL column without a default, through a default dropped right after.
+func (d *Postgres) AddColumnSQL(field Field) []string {
+	tableName := d.Quote(field.Table)
+	queries := []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", tableName, d.columnSQL(field))}
+	if NeedsBackfill(field) {
+		if zero := zeroValue(d, field.Type); zero != "" {
+			queries = []string{
+				fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s DEFAULT %s;", tableName, d.columnSQL(field), zero),
+				fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", tableName, d.Quote(field.Name)),
+			}
+		} else {
+			queries = append([]string{fmt.Sprintf("-- auto-table: %s.%s is NOT NULL without a default, fill the existing rows by hand", field.Table, field.Name)}, queries...)
+		}
+	}
+	if field.Comment != "" {
+		queries = append(queries, d.commentSQL(field.Table, field))
+	}
+	return queries
+}
+
+func (d *Postgres) DropColumnSQL(field Field) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", d.Quote(field.Table), d.Quote(field.Name))}
+}
+
+// ModifyColumnSQL alters the column in place. The serial types of AutoIncrement columns only exist in CREATE TABLE,
+// so the sequence behind a serial column is created, altered or dropped by statements of its own.
+func (d *Postgres) ModifyColumnSQL(oldField, newField Field) []string {
+	tableName := d.Quote(newField.Table)
+	var queries []string
+	if oldField.Name != newField.Name {
+		queries = append(queries, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", tableName, d.Quote(oldField.Name), d.Quote(newField.Name)))
+	}
+	column := d.Quote(newField.Name)
+	_, oldSerial := postgresSerialTypes[strings.ToUpper(oldField.Type)]
+	_, newSerial := postgresSerialTypes[strings.ToUpper(newField.Type)]
+	oldSequence := d.Quote(postgresSequenceName(oldField))
+	newSequence := d.Quote(postgresSequenceName(newField))
+	specs := []string{fmt.Sprintf("ALTER COLUMN %s TYPE %s", column, newField.Type)}
+	if newField.Nullable {
+		specs = append(specs, fmt.Sprintf("ALTER COLUMN %s DROP NOT NULL", column))
+	} else {
+		specs = append(specs, fmt.Sprintf("ALTER COLUMN %s SET NOT NULL", column))
+	}
+	switch {
+	case newField.AutoIncrement && !oldField.AutoIncrement && newSerial:
+		queries = append(queries, fmt.Sprintf("CREATE SEQUENCE %s AS %s OWNED BY %s.%s;", newSequence, newField.Type, tableName, column))
+		specs = append(specs, fmt.Sprintf("ALTER COLUMN %s SET DEFAULT nextval(%s)", column, d.QuoteString(newSequence)))
+	case newField.AutoIncrement && !oldField.AutoIncrement:
+		specs = append(specs, fmt.Sprintf("ALTER COLUMN %s ADD GENERATED BY DEFAULT AS IDENTITY", column))
+	case !newField.AutoIncrement && oldField.AutoIncrement && !oldSerial:
+		specs = append(specs, fmt.Sprintf("ALTER COLUMN %s DROP IDENTITY IF EXISTS", column))
+	}
+	if def := d.defaultValue(newField); def != "" {
+		specs = append(specs, fmt.Sprintf("ALTER COLUMN %s SET DEFAULT %s", column, def))
+	} else if !newField.AutoIncrement {
+		specs = append(specs, fmt.Sprintf("ALTER COLUMN %s DROP DEFAULT", column))
+	}
+	queries = append(queries, fmt.Sprintf("ALTER TABLE %s %s;", tableName, strings.Join(specs, ", ")))
+	switch {
+	case newField.AutoIncrement && oldField.AutoIncrement && newSerial && !strings.EqualFold(oldField.Type, newField.Type):
+		queries = append(queries, fmt.Sprintf("ALTER SEQUENCE %s AS %s;", oldSequence, newField.Type))
+	case newField.AutoIncrement && !oldField.AutoIncrement && newSerial:
+		// The sequence continues after the values the rows already have.
+		queries = append(queries, fmt.Sprintf("SELECT setval(%s, COALESCE(MAX(%s), 0) + 1, false) FROM %s;", d.QuoteString(newSequence), column, tableName))
+	case !newField.AutoIncrement && oldField.AutoIncrement && oldSerial:
+		queries = append(queries, fmt.Sprintf("DROP SEQUENCE IF EXISTS %s;", oldSequence))
+	}
+	if oldField.Comment != newField.Comment {
+		queries = append(queries, d.commentSQL(newField.Table, newField))
+	}
+	return queries
+}
+
+func (d *Postgres) ModifyPrimaryKeySQL(oldPrimaryKeys, newPrimaryKeys []Field) []string {
+	var tableName string
+	if len(newPrimaryKeys) > 0 {
+		tableName = newPrimaryKeys[0].Table
+	} else {
+		tableName = oldPrimaryKeys[0].Table
+	}
+	var specs []string
+	if len(oldPrimaryKeys) > 0 {
+		// Primary key constraints created inline are named <table>_pkey by PostgreSQL.
+		specs = append(specs, fmt.Sprintf("DROP CONSTRAINT %s", d.Quote(tableName+"_pkey")))
+	}
+	if len(newPrimaryKeys) > 0 {
+		pkColumns := make([]string, len(newPrimaryKeys))
+		for i, pk := range newPrimaryKeys {
+			pkColumns[i] = d.Quote(pk.Name)
+		}
+		specs = append(specs, fmt.Sprintf("ADD PRIMARY KEY (%s)", strings.Join(pkColumns, ", ")))
+	}
+	return []string{fmt.Sprintf("ALTER TABLE %s %s;", d.Quote(tableName), strings.Join(specs, ", "))}
+}
+
+func (d *Postgres) AddForeignKeySQL(table string, column string, reference ForeignKey) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(%s);",
+		d.Quote(table), d.Quote(ForeignKeyName(table, column)), d.Quote(column), d.Quote(reference.Table), d.Quote(reference.Column))}
+}
+
+func (d *Postgres) DropForeignKeySQL(table string, column string) []string {
+	// Foreign key constraints created inline are named <table>_<column>_fkey by PostgreSQL.
+	return []string{fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", d.Quote(table), d.Quote(ForeignKeyName(table, column)))}
+}
+
+func (d *Postgres) CreateIndexSQL(index Index) []string {
+	columns := make([]string, len(index.Columns))
+	for i, c := range index.Columns {
+		columns[i] = d.Quote(c)
+	}
+	indexName := d.Quote(index.Name)
+	tableName := d.Quote(index.Table)
+	column := strings.Join(columns, ", ")
+	if index.Unique {
+		return []string{fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s);", indexName, tableName, column)}
+	}
+	return []string{fmt.Sprintf("CREATE INDEX %s ON %s (%s);", indexName, tableName, column)}
+}
+
+func (d *Postgres) DropIndexSQL(index Index) []string {
+	// Index names are unique per schema in PostgreSQL, so the table is not part of the statement.
+	return []string{fmt.Sprintf("DROP INDEX IF EXISTS %s;", d.Quote(index.Name))}
+}
+
+func (d *Postgres) TransactionalDDL() bool {
+	return true
+}
+
+func (d *Postgres) Begin(conn *sql.Conn) (Transactioner, error) {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresTransaction{tx: tx}, nil
+}
+
+func (d *Postgres) Lock(conn *sql.Conn, name string) error {
+	_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_lock($1)", postgresLockKey(name))
+	return err
+}
+
+func (d *Postgres) Unlock(conn *sql.Conn, name string) error {
+	_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", postgresLockKey(name))
+	return err
+}
+
+// HasTable looks the table up in the search path, like the unqualified statements of the migrations do.
+func (d *Postgres) HasTable(conn *sql.Conn, name string) (bool, error) {
+	var found bool
+	err := conn.QueryRowContext(context.Background(), "SELECT to_regclass($1) IS NOT NULL", d.Quote(name)).Scan(&found)
+	return found, err
+}
+
+func (d *Postgres) columnSQL(f Field) string {
+	column := []string{d.Quote(f.Name), d.columnType(f)}
+	if !f.Nullable {
+		column = append(column, "NOT NULL")
+	}
+	if def := d.defaultValue(f); def != "" {
+		column = append(column, "DEFAULT", def)
+	}
+	if f.Extra != "" {
+		column = append(column, f.Extra)
+	}
+	return strings.Join(column, " ")
+}
+
+// columnType returns the type of the column, replacing integer types with SERIAL types or an IDENTITY clause for AutoIncrement columns.
+func (d *Postgres) columnType(f Field) string {
+	if !f.AutoIncrement {
+		return f.Type
+	}
+	if serial, ok := postgresSerialTypes[strings.ToUpper(f.Type)]; ok {
+		return serial
+	}
+	return f.Type + " GENERATED BY DEFAULT AS IDENTITY"
+}
+
+// postgresSequenceName names the sequence of a serial column like PostgreSQL does, <table>_<column>_seq.
+func postgresSequenceName(f Field) string {
+	return fmt.Sprintf("%s_%s_seq", f.Table, f.Name)
+}
+
+func (d *Postgres) defaultValue(f Field) string {
+	def := f.Default
+	if def != "" && d.isTextType(f) {
+		def = d.QuoteString(def)
+	}
+	return def
+}
+
+func (d *Postgres) commentSQL(table string, f Field) string {
+	comment := "NULL"
+	if f.Comment != "" {
+		comment = d.QuoteString(f.Comment)
+	}
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;", d.Quote(table), d.Quote(f.Name), comment)
+}
+
+func (d *Postgres) placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (d *Postgres) isTextType(f Field) bool {
+	typ := strings.ToUpper(f.Type)
+	for _, t := range []string{"VARCHAR", "CHAR", "TEXT", "CHARACTER"} {
+		if strings.HasPrefix(typ, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Postgres) defaultColumnType(name string) string {
+	switch name := strings.ToUpper(name); name {
+	case "VARCHAR":
+		return "VARCHAR(255)"
+	case "CHAR":
+		return "CHAR(1)"
+	case "NUMERIC", "DECIMAL":
+		return name + "(10,0)"
+	}
+	return name
+}
+
+// postgresLockKey converts a lock name into the integer key advisory locks take.
+func postgresLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+type postgresTransaction struct {
+	tx *sql.Tx
+}
+
+func (p *postgresTransaction) Exec(sql string, args ...interface{}) error {
+	_, err := p.tx.Exec(sql, args...)
+	return err
+}
+
+func (p *postgresTransaction) Commit() error {
+	return p.tx.Commit()
+}
+
+func (p *postgresTransaction) Rollback() error {
+	return p.tx.Rollback()
+}
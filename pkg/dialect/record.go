@@ -0,0 +1,211 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// recordSQL builds the record statements the dialects have in common.
+// Records are found by their primary key, or by the first column if the table has none.
+type recordSQL struct {
+	quote         func(s string) string
+	placeholder   func(n int) string // n starts at 1
+	defaultValues string             // Inserts a record of only default values, like DEFAULT VALUES
+}
+
+func (r recordSQL) findAllSQL(table Table) string {
+	return fmt.Sprintf("SELECT %s FROM %s;", strings.Join(r.selectColumns(table), ", "), r.quote(table.Name))
+}
+
+func (r recordSQL) findSQL(table Table) string {
+	where := r.where(table, 1)
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s;", strings.Join(r.selectColumns(table), ", "), r.quote(table.Name), where)
+}
+
+// createSQL leaves out auto increment columns, so that the database assigns them.
+func (r recordSQL) createSQL(table Table) string {
+	var columns, values []string
+	for _, f := range table.Fields {
+		if f.AutoIncrement {
+			continue
+		}
+		columns = append(columns, r.quote(f.Name))
+		values = append(values, r.placeholder(len(values)+1))
+	}
+	if len(columns) == 0 {
+		return fmt.Sprintf("INSERT INTO %s %s;", r.quote(table.Name), r.defaultValues)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", r.quote(table.Name), strings.Join(columns, ", "), strings.Join(values, ", "))
+}
+
+func (r recordSQL) deleteSQL(table Table) string {
+	where := r.where(table, 1)
+	return fmt.Sprintf("DELETE FROM %s WHERE %s;", r.quote(table.Name), where)
+}
+
+// updateSQL sets every column except the keys and auto increment columns, followed by the keys in the WHERE clause.
+func (r recordSQL) updateSQL(table Table) string {
+	keys := map[string]struct{}{}
+	for _, k := range r.keyColumns(table) {
+		keys[k] = struct{}{}
+	}
+	var set []string
+	for _, f := range table.Fields {
+		if _, ok := keys[f.Name]; ok || f.AutoIncrement {
+			continue
+		}
+		set = append(set, fmt.Sprintf("%s = %s", r.quote(f.Name), r.placeholder(len(set)+1)))
+	}
+	where := r.where(table, len(set)+1)
+	set = append(set, fmt.Sprintf("%s = CURRENT_TIMESTAMP", r.quote("updated_at")))
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s;", r.quote(table.Name), strings.Join(set, ", "), where)
+}
+
+func (r recordSQL) selectColumns(table Table) []string {
+	columns := make([]string, 0, len(table.Fields)+2)
+	for _, f := range table.Fields {
+		columns = append(columns, r.quote(f.Name))
+	}
+	return append(columns, r.quote("created_at"), r.quote("updated_at"))
+}
+
+// keyColumns returns the columns that identify a record.
+func (r recordSQL) keyColumns(table Table) []string {
+	if len(table.PrimaryKeys) > 0 {
+		return table.PrimaryKeys
+	}
+	if len(table.Fields) > 0 {
+		return []string{table.Fields[0].Name}
+	}
+	return nil
+}
+
+// where matches the key columns with placeholders numbered from start.
+func (r recordSQL) where(table Table, start int) string {
+	columns := r.keyColumns(table)
+	conditions := make([]string, len(columns))
+	for i, c := range columns {
+		conditions[i] = fmt.Sprintf("%s = %s", r.quote(c), r.placeholder(start+i))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// questionMark is the placeholder of MySQL and SQLite.
+func questionMark(int) string {
+	return "?"
+}
+
+// batchCreateSQL inserts the given number of records at once, leaving out auto increment columns like createSQL.
+func (r recordSQL) batchCreateSQL(table Table, rows int) string {
+	var columns []string
+	for _, f := range table.Fields {
+		if !f.AutoIncrement {
+			columns = append(columns, r.quote(f.Name))
+		}
+	}
+	if len(columns) == 0 || rows < 1 {
+		return r.createSQL(table)
+	}
+	values := make([]string, rows)
+	n := 1
+	for i := range values {
+		row := make([]string, len(columns))
+		for j := range row {
+			row[j] = r.placeholder(n)
+			n++
+		}
+		values[i] = "(" + strings.Join(row, ", ") + ")"
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s;", r.quote(table.Name), strings.Join(columns, ", "), strings.Join(values, ", "))
+}
+
+// paginateSQL returns the page of records after the given key, ordered by the key.
+// The last parameter is the number of records in a page.
+func (r recordSQL) paginateSQL(table Table) string {
+	keyColumns := r.keyColumns(table)
+	columns := make([]string, len(keyColumns))
+	values := make([]string, len(keyColumns))
+	for i, c := range keyColumns {
+		columns[i] = r.quote(c)
+		values[i] = r.placeholder(i + 1)
+	}
+	condition := fmt.Sprintf("%s > %s", columns[0], values[0])
+	if len(columns) > 1 {
+		condition = fmt.Sprintf("(%s) > (%s)", strings.Join(columns, ", "), strings.Join(values, ", "))
+	}
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT %s;",
+		strings.Join(r.selectColumns(table), ", "), r.quote(table.Name), condition, strings.Join(columns, ", "), r.placeholder(len(columns)+1))
+}
+
+// findByIndexSQL finds a record by the columns of an index.
+func (r recordSQL) findByIndexSQL(table Table, index Index) string {
+	conditions := make([]string, len(index.Columns))
+	for i, c := range index.Columns {
+		conditions[i] = fmt.Sprintf("%s = %s", r.quote(c), r.placeholder(i+1))
+	}
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s;", strings.Join(r.selectColumns(table), ", "), r.quote(table.Name), strings.Join(conditions, " AND "))
+}
+
+// upsertColumns returns every column, including auto increment columns since the key decides between insert and update,
+// and the assignments of the columns that are not keys. format writes the new value of a column.
+func (r recordSQL) upsertColumns(table Table, format func(column string) string) (columns, values, set []string) {
+	keys := map[string]struct{}{}
+	for _, k := range r.keyColumns(table) {
+		keys[k] = struct{}{}
+	}
+	for _, f := range table.Fields {
+		columns = append(columns, r.quote(f.Name))
+		values = append(values, r.placeholder(len(values)+1))
+		if _, ok := keys[f.Name]; !ok {
+			set = append(set, fmt.Sprintf("%s = %s", r.quote(f.Name), format(r.quote(f.Name))))
+		}
+	}
+	set = append(set, fmt.Sprintf("%s = CURRENT_TIMESTAMP", r.quote("updated_at")))
+	return
+}
+
+// onConflictSQL is the upsert of PostgreSQL and SQLite.
+func (r recordSQL) onConflictSQL(table Table) string {
+	columns, values, set := r.upsertColumns(table, func(column string) string {
+		return "EXCLUDED." + column
+	})
+	keyColumns := r.keyColumns(table)
+	conflict := make([]string, len(keyColumns))
+	for i, c := range keyColumns {
+		conflict[i] = r.quote(c)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s;",
+		r.quote(table.Name), strings.Join(columns, ", "), strings.Join(values, ", "), strings.Join(conflict, ", "), strings.Join(set, ", "))
+}
+
+// parentSQL joins the table to find the parent of one of its records. The tables are aliased since a table can reference itself.
+func (r recordSQL) parentSQL(table Table, column string, parent Table, parentColumn string) string {
+	keyColumns := r.keyColumns(table)
+	conditions := make([]string, len(keyColumns))
+	for i, c := range keyColumns {
+		conditions[i] = fmt.Sprintf("c.%s = %s", r.quote(c), r.placeholder(i+1))
+	}
+	return fmt.Sprintf("SELECT %s FROM %s p JOIN %s c ON c.%s = p.%s WHERE %s;",
+		strings.Join(r.aliasedColumns(parent, "p"), ", "), r.quote(parent.Name), r.quote(table.Name),
+		r.quote(column), r.quote(parentColumn), strings.Join(conditions, " AND "))
+}
+
+func (r recordSQL) childrenSQL(table Table, column string) string {
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s;", strings.Join(r.selectColumns(table), ", "), r.quote(table.Name), r.quote(column), r.placeholder(1))
+}
+
+// throughSQL joins the cross reference table to find the records linked to a value.
+func (r recordSQL) throughSQL(table Table, column string, through Table, from string, to string) string {
+	return fmt.Sprintf("SELECT %s FROM %s t JOIN %s x ON x.%s = t.%s WHERE x.%s = %s;",
+		strings.Join(r.aliasedColumns(table, "t"), ", "), r.quote(table.Name), r.quote(through.Name),
+		r.quote(to), r.quote(column), r.quote(from), r.placeholder(1))
+}
+
+// aliasedColumns returns selectColumns qualified by the alias of the table.
+func (r recordSQL) aliasedColumns(table Table, alias string) []string {
+	columns := r.selectColumns(table)
+	for i, c := range columns {
+		columns[i] = alias + "." + c
+	}
+	return columns
+}
@@ -0,0 +1,60 @@
+package dialect
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory creates a new instance of a dialect.
+type Factory func() Dialect
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+func init() {
+	Register("mysql", NewMySQL)
+	Register("postgres", NewPostgres)
+	Register("sqlite", NewSQLite)
+}
+
+// Register makes a dialect available by the provided name.
+// If Register is called twice with the same name or if factory is nil, it panics.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("auto-table: Register dialect factory is nil")
+	}
+	name = strings.ToLower(name)
+	if _, dup := registry[name]; dup {
+		panic("auto-table: Register called twice for dialect " + name)
+	}
+	registry[name] = factory
+}
+
+// New creates the dialect registered by the provided name.
+func New(name string) (Dialect, error) {
+	registryMu.RLock()
+	factory, ok := registry[strings.ToLower(name)]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("auto-table: unknown dialect %q (registered: %s)", name, strings.Join(Names(), ", "))
+	}
+	return factory(), nil
+}
+
+// Names returns a sorted list of the names of the registered dialects.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,445 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/naoina/go-stringutil"
+	"strings"
+	"time"
+)
+
+var (
+	_ TableRebuilder  = &SQLite{}
+	_ Migrator        = &SQLite{}
+	_ SessionModifier = &SQLite{}
+	_ RecordQuerier   = &SQLite{}
+	_ RelationQuerier = &SQLite{}
+)
+
+const (
+	// sqliteLockTable holds a row for each lock taken by Lock.
+	sqliteLockTable = "auto_table_locks"
+
+	// sqliteLockInterval is how often Lock retries while the lock is held.
+	sqliteLockInterval = 100 * time.Millisecond
+)
+
+var (
+	// sqliteColumnTypes only uses declared types whose affinity is obvious to SQLite and to database drivers.
+	// See https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+	sqliteColumnTypes = []*ColumnType{
+		{
+			Types:           []string{"TEXT"},
+			GoTypes:         []string{"string"},
+			GoNullableTypes: []string{"*string", "sql.NullString"},
+		},
+		{
+			Types:           []string{"BLOB"},
+			GoTypes:         []string{"[]byte"},
+			GoNullableTypes: []string{"[]byte"},
+		},
+		{
+			Types:           []string{"INTEGER"},
+			GoTypes:         []string{"int64", "int", "int32", "int16", "int8", "uint", "uint64", "uint32", "uint16", "uint8"},
+			GoNullableTypes: []string{"*int64", "sql.NullInt64", "sql.NullInt32", "sql.NullInt16"},
+		},
+		{
+			Types:           []string{"REAL"},
+			GoTypes:         []string{"float64", "float32"},
+			GoNullableTypes: []string{"*float64", "sql.NullFloat64"},
+		},
+		{
+			// BOOLEAN has NUMERIC affinity, drivers map it to bool.
+			Types:           []string{"BOOLEAN"},
+			GoTypes:         []string{"bool"},
+			GoNullableTypes: []string{"*bool", "sql.NullBool"},
+		},
+		{
+			// DATETIME has NUMERIC affinity, drivers parse it into time.Time.
+			Types:           []string{"DATETIME", "TIMESTAMP", "DATE"},
+			GoTypes:         []string{"time.Time"},
+			GoNullableTypes: []string{"*time.Time", "sql.NullTime"},
+		},
+	}
+)
+
+type SQLite struct {
+	columnTypeMap   map[string]*ColumnType
+	nullableTypeMap map[string]struct{}
+}
+
+func NewSQLite() Dialect {
+	d := &SQLite{
+		columnTypeMap:   map[string]*ColumnType{},
+		nullableTypeMap: map[string]struct{}{},
+	}
+
+	for _, types := range [][]*ColumnType{sqliteColumnTypes} {
+		for _, t := range types {
+			for _, tt := range t.allGoTypes() {
+				d.columnTypeMap[tt] = t
+			}
+			for _, tt := range t.filteredNullableGoTypes() {
+				d.nullableTypeMap[tt] = struct{}{}
+			}
+		}
+	}
+	return d
+}
+
+func (d *SQLite) ColumnType(name string) string {
+	if t, ok := d.columnTypeMap[name]; ok {
+		name, _, _, _ = t.findType(name)
+	}
+	return strings.ToUpper(name)
+}
+
+func (d *SQLite) GoType(name string, nullable bool) string {
+	name = strings.ToUpper(name)
+	for _, t := range sqliteColumnTypes {
+		if typ, found := t.findGoType(name, nullable, false); found {
+			return typ
+		}
+	}
+	if strings.IndexByte(name, '(') >= 0 {
+		return d.GoType(strings.TrimSpace(trimParens(name)), nullable)
+	}
+	switch affinity := sqliteAffinity(name); affinity {
+	case "NUMERIC":
+		return d.GoType("REAL", nullable)
+	default:
+		return d.GoType(affinity, nullable)
+	}
+}
+
+func (d *SQLite) IsNullable(name string) bool {
+	_, ok := d.nullableTypeMap[name]
+	return ok
+}
+
+func (d *SQLite) ImportPackage(schema ColumnSchema) string {
+	switch schema.DataType() {
+	case "datetime", "timestamp", "date":
+		return "time"
+	}
+	return ""
+}
+
+func (d *SQLite) Quote(s string) string {
+	return fmt.Sprintf(`"%s"`, strings.Replace(s, `"`, `""`, -1))
+}
+
+func (d *SQLite) QuoteString(s string) string {
+	return fmt.Sprintf("'%s'", strings.Replace(s, "'", "''", -1))
+}
+
+func (d *SQLite) CreateTableSQL(table Table) []string {
+	return []string{d.createTableSQL(table, table.Name)}
+}
+
+func (d *SQLite) DropTableSQL(table Table) []string {
+	return []string{fmt.Sprintf("DROP TABLE IF EXISTS %s;", d.Quote(table.Name))}
+}
+
+func (d *SQLite) FindAllSQL(table Table) []string {
+	return []string{d.records().findAllSQL(table)}
+}
+
+func (d *SQLite) FindSQL(table Table) []string {
+	return []string{d.records().findSQL(table)}
+}
+
+func (d *SQLite) CreateSQL(table Table) []string {
+	return []string{d.records().createSQL(table)}
+}
+
+func (d *SQLite) DeleteSQL(table Table) []string {
+	return []string{d.records().deleteSQL(table)}
+}
+
+func (d *SQLite) UpdateSQL(table Table) []string {
+	return []string{d.records().updateSQL(table)}
+}
+
+func (d *SQLite) UpsertSQL(table Table) []string {
+	return []string{d.records().onConflictSQL(table)}
+}
+
+func (d *SQLite) BatchCreateSQL(table Table, rows int) []string {
+	return []string{d.records().batchCreateSQL(table, rows)}
+}
+
+func (d *SQLite) PaginateSQL(table Table) []string {
+	return []string{d.records().paginateSQL(table)}
+}
+
+func (d *SQLite) FindByIndexSQL(table Table, index Index) []string {
+	return []string{d.records().findByIndexSQL(table, index)}
+}
+
+func (d *SQLite) ParentSQL(table Table, column string, parent Table, parentColumn string) []string {
+	return []string{d.records().parentSQL(table, column, parent, parentColumn)}
+}
+
+func (d *SQLite) ChildrenSQL(table Table, column string) []string {
+	return []string{d.records().childrenSQL(table, column)}
+}
+
+func (d *SQLite) ThroughSQL(table Table, column string, through Table, from string, to string) []string {
+	return []string{d.records().throughSQL(table, column, through, from, to)}
+}
+
+func (d *SQLite) records() recordSQL {
+	return recordSQL{quote: d.Quote, placeholder: questionMark, defaultValues: "DEFAULT VALUES"}
+}
+
+func (d *SQLite) AddColumnSQL(field Field) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", d.Quote(field.Table), d.columnSQL(field, false))}
+}
+
+// DropColumnSQL needs SQLite 3.35.0, and fails on indexed or constrained columns.
+// The migrations of changed tables rebuild them through RebuildTableSQL instead.
+func (d *SQLite) DropColumnSQL(field Field) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", d.Quote(field.Table), d.Quote(field.Name))}
+}
+
+// ModifyColumnSQL can only rename the column, since SQLite has no other way to change a column in place.
+// The migrations of changed tables rebuild them through RebuildTableSQL instead.
+func (d *SQLite) ModifyColumnSQL(oldField, newField Field) []string {
+	if oldField.Name != newField.Name {
+		return []string{fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", d.Quote(newField.Table), d.Quote(oldField.Name), d.Quote(newField.Name))}
+	}
+	return []string{fmt.Sprintf("-- auto-table: %s.%s cannot be modified in place, rebuild the table manually", d.Quote(newField.Table), d.Quote(newField.Name))}
+}
+
+// ForeignKeysSQL turns foreign key enforcement on or off, which SQLite only allows outside of a transaction.
+func (d *SQLite) ForeignKeysSQL(on bool) []string {
+	if on {
+		return []string{"PRAGMA foreign_keys = ON;"}
+	}
+	return []string{"PRAGMA foreign_keys = OFF;"}
+}
+
+// RebuildTableSQL recreates the table with the new definition and copies the columns that exist in both definitions,
+// following the create new, copy, drop, rename pattern. The foreign keys have to be off, see ForeignKeysSQL.
+// See https://www.sqlite.org/lang_altertable.html#otheralter
+func (d *SQLite) RebuildTableSQL(oldTable, newTable Table) []string {
+	oldColumns := map[string]struct{}{}
+	for _, f := range oldTable.Fields {
+		oldColumns[f.Name] = struct{}{}
+	}
+	newColumns := []string{d.Quote("created_at"), d.Quote("updated_at")}
+	selectColumns := []string{d.Quote("created_at"), d.Quote("updated_at")}
+	for _, f := range newTable.Fields {
+		if _, ok := oldColumns[f.Name]; !ok {
+			// A new NOT NULL column without a default is filled with the zero value.
+			if zero := d.zeroValue(f); zero != "" && NeedsBackfill(f) {
+				newColumns = append(newColumns, d.Quote(f.Name))
+				selectColumns = append(selectColumns, zero)
+			}
+			continue
+		}
+		newColumns = append(newColumns, d.Quote(f.Name))
+		selectColumns = append(selectColumns, d.Quote(f.Name))
+	}
+
+	tmpName := fmt.Sprintf("_%s_new", newTable.Name)
+	queries := []string{
+		d.createTableSQL(newTable, tmpName),
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s;", d.Quote(tmpName), strings.Join(newColumns, ", "), strings.Join(selectColumns, ", "), d.Quote(oldTable.Name)),
+		fmt.Sprintf("DROP TABLE %s;", d.Quote(oldTable.Name)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", d.Quote(tmpName), d.Quote(newTable.Name)),
+	}
+	// Indexes are dropped together with the old table.
+	for _, index := range newTable.Indexes {
+		queries = append(queries, d.CreateIndexSQL(index)...)
+	}
+	return append(queries, d.foreignKeyCheckSQL(newTable.Name)...)
+}
+
+// foreignKeyCheckSQL fails the migration if rows reference missing rows, which goes unnoticed while the foreign keys are off.
+// PRAGMA foreign_key_check only lists the violations, so their count is checked by a constraint of a temporary table.
+// The whole database is checked, since the rows of other tables may reference the rebuilt table.
+func (d *SQLite) foreignKeyCheckSQL(table string) []string {
+	check := d.Quote(fmt.Sprintf("_%s_foreign_key_check", table))
+	return []string{
+		fmt.Sprintf("CREATE TEMP TABLE %s (%s INTEGER CONSTRAINT %s CHECK (%s = 0));",
+			check, d.Quote("violations"), d.Quote("foreign keys are violated"), d.Quote("violations")),
+		fmt.Sprintf("INSERT INTO %s SELECT COUNT(*) FROM pragma_foreign_key_check;", check),
+		fmt.Sprintf("DROP TABLE %s;", check),
+	}
+}
+
+func (d *SQLite) CreateIndexSQL(index Index) []string {
+	columns := make([]string, len(index.Columns))
+	for i, c := range index.Columns {
+		columns[i] = d.Quote(c)
+	}
+	indexName := d.Quote(index.Name)
+	tableName := d.Quote(index.Table)
+	column := strings.Join(columns, ", ")
+	if index.Unique {
+		return []string{fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s);", indexName, tableName, column)}
+	}
+	return []string{fmt.Sprintf("CREATE INDEX %s ON %s (%s);", indexName, tableName, column)}
+}
+
+func (d *SQLite) DropIndexSQL(index Index) []string {
+	return []string{fmt.Sprintf("DROP INDEX IF EXISTS %s;", d.Quote(index.Name))}
+}
+
+// IsSessionStatement reports whether the statement is PRAGMA foreign_keys, which SQLite ignores inside a transaction.
+// The migrations rebuilding tables start and end with it, see ForeignKeysSQL.
+func (d *SQLite) IsSessionStatement(query string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.Join(strings.Fields(query), " ")), "PRAGMA FOREIGN_KEYS ")
+}
+
+func (d *SQLite) TransactionalDDL() bool {
+	return true
+}
+
+func (d *SQLite) Begin(conn *sql.Conn) (Transactioner, error) {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteTransaction{tx: tx}, nil
+}
+
+// Lock inserts the name into the lock table, waiting while another connection holds the lock.
+// SQLite has no advisory locks, and its own locks are held only until the end of a transaction.
+// A lock left behind by a process that died is released by deleting its row.
+func (d *SQLite) Lock(conn *sql.Conn, name string) error {
+	ctx := context.Background()
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s TEXT PRIMARY KEY)", d.Quote(sqliteLockTable), d.Quote("name")))
+	if err != nil {
+		return err
+	}
+	for {
+		result, err := conn.ExecContext(ctx, fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (?)", d.Quote(sqliteLockTable), d.Quote("name")), name)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil || n > 0 {
+			return err
+		}
+		time.Sleep(sqliteLockInterval)
+	}
+}
+
+func (d *SQLite) Unlock(conn *sql.Conn, name string) error {
+	_, err := conn.ExecContext(context.Background(), fmt.Sprintf("DELETE FROM %s WHERE %s = ?", d.Quote(sqliteLockTable), d.Quote("name")), name)
+	return err
+}
+
+func (d *SQLite) HasTable(conn *sql.Conn, name string) (bool, error) {
+	var n int
+	err := conn.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n)
+	return n > 0, err
+}
+
+func (d *SQLite) createTableSQL(table Table, name string) string {
+	// AUTOINCREMENT is only allowed on a single INTEGER PRIMARY KEY column, which has to be declared inline.
+	rowID := ""
+	if len(table.PrimaryKeys) == 1 {
+		for _, f := range table.Fields {
+			if f.Name == table.PrimaryKeys[0] && f.AutoIncrement {
+				rowID = f.Name
+			}
+		}
+	}
+
+	columns := make([]string, len(table.Fields))
+	for i, f := range table.Fields {
+		columns[i] = d.columnSQL(f, f.Name == rowID)
+	}
+	columns = append(columns, fmt.Sprintf("%s DATETIME DEFAULT CURRENT_TIMESTAMP", d.Quote("created_at")))
+	columns = append(columns, fmt.Sprintf("%s DATETIME DEFAULT CURRENT_TIMESTAMP", d.Quote("updated_at")))
+
+	if len(table.PrimaryKeys) > 0 && rowID == "" {
+		pkColumns := make([]string, len(table.PrimaryKeys))
+		for i, pk := range table.PrimaryKeys {
+			pkColumns[i] = d.Quote(pk)
+		}
+		columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(pkColumns, ", ")))
+	}
+	if len(table.ForeignKeys) > 0 {
+		for _, name := range ForeignKeyNames(table) {
+			reference := table.ForeignKeys[name]
+			columns = append(columns,
+				fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s(%s)",
+					d.Quote(stringutil.ToSnakeCase(name)),
+					d.Quote(stringutil.ToSnakeCase(reference.Table)),
+					d.Quote(stringutil.ToSnakeCase(reference.Column))))
+		}
+	}
+
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n"+
+		"  %s\n"+
+		")", d.Quote(name), strings.Join(columns, ",\n  "))
+	if table.Option != "" {
+		query += " " + table.Option
+	}
+	return query + ";"
+}
+
+func (d *SQLite) columnSQL(f Field, rowID bool) string {
+	if rowID {
+		return fmt.Sprintf("%s INTEGER PRIMARY KEY AUTOINCREMENT", d.Quote(f.Name))
+	}
+	column := []string{d.Quote(f.Name), f.Type}
+	if !f.Nullable {
+		column = append(column, "NOT NULL")
+	}
+	if def := f.Default; def != "" {
+		if sqliteAffinity(f.Type) == "TEXT" {
+			def = d.QuoteString(def)
+		}
+		column = append(column, "DEFAULT", def)
+	}
+	if f.Extra != "" {
+		column = append(column, f.Extra)
+	}
+	return strings.Join(column, " ")
+}
+
+func (d *SQLite) zeroValue(f Field) string {
+	if sqliteAffinity(f.Type) == "BLOB" {
+		return "X''"
+	}
+	return zeroValue(d, f.Type)
+}
+
+// sqliteAffinity determines the column affinity of a declared type by the rules SQLite applies in order.
+func sqliteAffinity(typ string) string {
+	typ = strings.ToUpper(typ)
+	switch {
+	case strings.Contains(typ, "INT"):
+		return "INTEGER"
+	case strings.Contains(typ, "CHAR"), strings.Contains(typ, "CLOB"), strings.Contains(typ, "TEXT"):
+		return "TEXT"
+	case strings.Contains(typ, "BLOB"), typ == "":
+		return "BLOB"
+	case strings.Contains(typ, "REAL"), strings.Contains(typ, "FLOA"), strings.Contains(typ, "DOUB"):
+		return "REAL"
+	}
+	return "NUMERIC"
+}
+
+type sqliteTransaction struct {
+	tx *sql.Tx
+}
+
+func (s *sqliteTransaction) Exec(sql string, args ...interface{}) error {
+	_, err := s.tx.Exec(sql, args...)
+	return err
+}
+
+func (s *sqliteTransaction) Commit() error {
+	return s.tx.Commit()
+}
+
+func (s *sqliteTransaction) Rollback() error {
+	return s.tx.Rollback()
+}
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS `user` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(255) NOT NULL DEFAULT 'guest' COMMENT 'login name',
+  `bio` TEXT,
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `micropost` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `author_id` BIGINT NOT NULL,
+  `editor_id` BIGINT,
+  `content` TEXT NOT NULL,
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  CONSTRAINT `micropost_author_id_fkey` FOREIGN KEY (`author_id`) REFERENCES `user`(`id`),
+  CONSTRAINT `micropost_editor_id_fkey` FOREIGN KEY (`editor_id`) REFERENCES `user`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `micropost_tag` (
+  `micropost_id` BIGINT NOT NULL,
+  `tag_id` BIGINT NOT NULL,
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`micropost_id`, `tag_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `country` (
+  `code` VARCHAR(2) NOT NULL,
+  `name` VARCHAR(80) NOT NULL,
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`code`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
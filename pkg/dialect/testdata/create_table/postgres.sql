@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS "user" (
+  "id" BIGSERIAL NOT NULL,
+  "name" VARCHAR(255) NOT NULL DEFAULT 'guest',
+  "bio" TEXT,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id")
+);
+
+COMMENT ON COLUMN "user"."name" IS 'login name';
+
+CREATE TABLE IF NOT EXISTS "micropost" (
+  "id" BIGSERIAL NOT NULL,
+  "author_id" BIGINT NOT NULL,
+  "editor_id" BIGINT,
+  "content" TEXT NOT NULL,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("author_id") REFERENCES "user"("id"),
+  FOREIGN KEY ("editor_id") REFERENCES "user"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "micropost_tag" (
+  "micropost_id" BIGINT NOT NULL,
+  "tag_id" BIGINT NOT NULL,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("micropost_id", "tag_id")
+);
+
+CREATE TABLE IF NOT EXISTS "country" (
+  "code" VARCHAR(2) NOT NULL,
+  "name" VARCHAR(80) NOT NULL,
+  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("code")
+) WITH (fillfactor = 70);
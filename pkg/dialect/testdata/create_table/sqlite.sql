@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS "user" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "name" VARCHAR(255) NOT NULL DEFAULT 'guest',
+  "bio" TEXT,
+  "created_at" DATETIME DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS "micropost" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "author_id" BIGINT NOT NULL,
+  "editor_id" BIGINT,
+  "content" TEXT NOT NULL,
+  "created_at" DATETIME DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" DATETIME DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY ("author_id") REFERENCES "user"("id"),
+  FOREIGN KEY ("editor_id") REFERENCES "user"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "micropost_tag" (
+  "micropost_id" BIGINT NOT NULL,
+  "tag_id" BIGINT NOT NULL,
+  "created_at" DATETIME DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" DATETIME DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("micropost_id", "tag_id")
+);
+
+CREATE TABLE IF NOT EXISTS "country" (
+  "code" VARCHAR(2) NOT NULL,
+  "name" VARCHAR(80) NOT NULL,
+  "created_at" DATETIME DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" DATETIME DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("code")
+) WITHOUT ROWID;
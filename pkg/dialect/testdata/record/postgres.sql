@@ -0,0 +1,40 @@
+-- user
+SELECT "id", "name", "bio", "created_at", "updated_at" FROM "user";
+SELECT "id", "name", "bio", "created_at", "updated_at" FROM "user" WHERE "id" = $1;
+INSERT INTO "user" ("name", "bio") VALUES ($1, $2);
+UPDATE "user" SET "name" = $1, "bio" = $2, "updated_at" = CURRENT_TIMESTAMP WHERE "id" = $3;
+DELETE FROM "user" WHERE "id" = $1;
+INSERT INTO "user" ("id", "name", "bio") VALUES ($1, $2, $3) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "bio" = EXCLUDED."bio", "updated_at" = CURRENT_TIMESTAMP;
+INSERT INTO "user" ("name", "bio") VALUES ($1, $2), ($3, $4);
+SELECT "id", "name", "bio", "created_at", "updated_at" FROM "user" WHERE "id" > $1 ORDER BY "id" LIMIT $2;
+SELECT "id", "name", "bio", "created_at", "updated_at" FROM "user" WHERE "name" = $1;
+
+-- micropost
+SELECT "id", "author_id", "editor_id", "content", "created_at", "updated_at" FROM "micropost";
+SELECT "id", "author_id", "editor_id", "content", "created_at", "updated_at" FROM "micropost" WHERE "id" = $1;
+INSERT INTO "micropost" ("author_id", "editor_id", "content") VALUES ($1, $2, $3);
+UPDATE "micropost" SET "author_id" = $1, "editor_id" = $2, "content" = $3, "updated_at" = CURRENT_TIMESTAMP WHERE "id" = $4;
+DELETE FROM "micropost" WHERE "id" = $1;
+INSERT INTO "micropost" ("id", "author_id", "editor_id", "content") VALUES ($1, $2, $3, $4) ON CONFLICT ("id") DO UPDATE SET "author_id" = EXCLUDED."author_id", "editor_id" = EXCLUDED."editor_id", "content" = EXCLUDED."content", "updated_at" = CURRENT_TIMESTAMP;
+INSERT INTO "micropost" ("author_id", "editor_id", "content") VALUES ($1, $2, $3), ($4, $5, $6);
+SELECT "id", "author_id", "editor_id", "content", "created_at", "updated_at" FROM "micropost" WHERE "id" > $1 ORDER BY "id" LIMIT $2;
+
+-- micropost_tag
+SELECT "micropost_id", "tag_id", "created_at", "updated_at" FROM "micropost_tag";
+SELECT "micropost_id", "tag_id", "created_at", "updated_at" FROM "micropost_tag" WHERE "micropost_id" = $1 AND "tag_id" = $2;
+INSERT INTO "micropost_tag" ("micropost_id", "tag_id") VALUES ($1, $2);
+UPDATE "micropost_tag" SET "updated_at" = CURRENT_TIMESTAMP WHERE "micropost_id" = $1 AND "tag_id" = $2;
+DELETE FROM "micropost_tag" WHERE "micropost_id" = $1 AND "tag_id" = $2;
+INSERT INTO "micropost_tag" ("micropost_id", "tag_id") VALUES ($1, $2) ON CONFLICT ("micropost_id", "tag_id") DO UPDATE SET "updated_at" = CURRENT_TIMESTAMP;
+INSERT INTO "micropost_tag" ("micropost_id", "tag_id") VALUES ($1, $2), ($3, $4);
+SELECT "micropost_id", "tag_id", "created_at", "updated_at" FROM "micropost_tag" WHERE ("micropost_id", "tag_id") > ($1, $2) ORDER BY "micropost_id", "tag_id" LIMIT $3;
+
+-- country
+SELECT "code", "name", "created_at", "updated_at" FROM "country";
+SELECT "code", "name", "created_at", "updated_at" FROM "country" WHERE "code" = $1;
+INSERT INTO "country" ("code", "name") VALUES ($1, $2);
+UPDATE "country" SET "name" = $1, "updated_at" = CURRENT_TIMESTAMP WHERE "code" = $2;
+DELETE FROM "country" WHERE "code" = $1;
+INSERT INTO "country" ("code", "name") VALUES ($1, $2) ON CONFLICT ("code") DO UPDATE SET "name" = EXCLUDED."name", "updated_at" = CURRENT_TIMESTAMP;
+INSERT INTO "country" ("code", "name") VALUES ($1, $2), ($3, $4);
+SELECT "code", "name", "created_at", "updated_at" FROM "country" WHERE "code" > $1 ORDER BY "code" LIMIT $2;
@@ -0,0 +1,40 @@
+-- user
+SELECT "id", "name", "bio", "created_at", "updated_at" FROM "user";
+SELECT "id", "name", "bio", "created_at", "updated_at" FROM "user" WHERE "id" = ?;
+INSERT INTO "user" ("name", "bio") VALUES (?, ?);
+UPDATE "user" SET "name" = ?, "bio" = ?, "updated_at" = CURRENT_TIMESTAMP WHERE "id" = ?;
+DELETE FROM "user" WHERE "id" = ?;
+INSERT INTO "user" ("id", "name", "bio") VALUES (?, ?, ?) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "bio" = EXCLUDED."bio", "updated_at" = CURRENT_TIMESTAMP;
+INSERT INTO "user" ("name", "bio") VALUES (?, ?), (?, ?);
+SELECT "id", "name", "bio", "created_at", "updated_at" FROM "user" WHERE "id" > ? ORDER BY "id" LIMIT ?;
+SELECT "id", "name", "bio", "created_at", "updated_at" FROM "user" WHERE "name" = ?;
+
+-- micropost
+SELECT "id", "author_id", "editor_id", "content", "created_at", "updated_at" FROM "micropost";
+SELECT "id", "author_id", "editor_id", "content", "created_at", "updated_at" FROM "micropost" WHERE "id" = ?;
+INSERT INTO "micropost" ("author_id", "editor_id", "content") VALUES (?, ?, ?);
+UPDATE "micropost" SET "author_id" = ?, "editor_id" = ?, "content" = ?, "updated_at" = CURRENT_TIMESTAMP WHERE "id" = ?;
+DELETE FROM "micropost" WHERE "id" = ?;
+INSERT INTO "micropost" ("id", "author_id", "editor_id", "content") VALUES (?, ?, ?, ?) ON CONFLICT ("id") DO UPDATE SET "author_id" = EXCLUDED."author_id", "editor_id" = EXCLUDED."editor_id", "content" = EXCLUDED."content", "updated_at" = CURRENT_TIMESTAMP;
+INSERT INTO "micropost" ("author_id", "editor_id", "content") VALUES (?, ?, ?), (?, ?, ?);
+SELECT "id", "author_id", "editor_id", "content", "created_at", "updated_at" FROM "micropost" WHERE "id" > ? ORDER BY "id" LIMIT ?;
+
+-- micropost_tag
+SELECT "micropost_id", "tag_id", "created_at", "updated_at" FROM "micropost_tag";
+SELECT "micropost_id", "tag_id", "created_at", "updated_at" FROM "micropost_tag" WHERE "micropost_id" = ? AND "tag_id" = ?;
+INSERT INTO "micropost_tag" ("micropost_id", "tag_id") VALUES (?, ?);
+UPDATE "micropost_tag" SET "updated_at" = CURRENT_TIMESTAMP WHERE "micropost_id" = ? AND "tag_id" = ?;
+DELETE FROM "micropost_tag" WHERE "micropost_id" = ? AND "tag_id" = ?;
+INSERT INTO "micropost_tag" ("micropost_id", "tag_id") VALUES (?, ?) ON CONFLICT ("micropost_id", "tag_id") DO UPDATE SET "updated_at" = CURRENT_TIMESTAMP;
+INSERT INTO "micropost_tag" ("micropost_id", "tag_id") VALUES (?, ?), (?, ?);
+SELECT "micropost_id", "tag_id", "created_at", "updated_at" FROM "micropost_tag" WHERE ("micropost_id", "tag_id") > (?, ?) ORDER BY "micropost_id", "tag_id" LIMIT ?;
+
+-- country
+SELECT "code", "name", "created_at", "updated_at" FROM "country";
+SELECT "code", "name", "created_at", "updated_at" FROM "country" WHERE "code" = ?;
+INSERT INTO "country" ("code", "name") VALUES (?, ?);
+UPDATE "country" SET "name" = ?, "updated_at" = CURRENT_TIMESTAMP WHERE "code" = ?;
+DELETE FROM "country" WHERE "code" = ?;
+INSERT INTO "country" ("code", "name") VALUES (?, ?) ON CONFLICT ("code") DO UPDATE SET "name" = EXCLUDED."name", "updated_at" = CURRENT_TIMESTAMP;
+INSERT INTO "country" ("code", "name") VALUES (?, ?), (?, ?);
+SELECT "code", "name", "created_at", "updated_at" FROM "country" WHERE "code" > ? ORDER BY "code" LIMIT ?;
@@ -0,0 +1,195 @@
+package diff
+
+import (
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/naoina/go-stringutil"
+	"sort"
+	"strings"
+)
+
+// Diff holds the changes that turn one set of tables into another.
+type Diff struct {
+	AddedTables    []dialect.Table
+	DroppedTables  []dialect.Table
+	ModifiedTables []*TableDiff
+}
+
+// TableDiff holds the changes of a table that exists in both sets.
+type TableDiff struct {
+	Old                dialect.Table
+	New                dialect.Table
+	AddedColumns       []dialect.Field
+	DroppedColumns     []dialect.Field
+	ModifiedColumns    []*ColumnDiff
+	PrimaryKeyChanged  bool
+	AddedIndexes       []dialect.Index
+	DroppedIndexes     []dialect.Index
+	AddedForeignKeys   map[string]dialect.ForeignKey // map[columnName]reference
+	DroppedForeignKeys map[string]dialect.ForeignKey // map[columnName]reference
+}
+
+// ColumnDiff holds both definitions of a column whose definition changed.
+type ColumnDiff struct {
+	Old dialect.Field
+	New dialect.Field
+}
+
+// Compare compares the old tables with the new tables. Tables and columns are matched by name, so a rename is reported as a drop and an addition.
+func Compare(oldTables, newTables []dialect.Table) *Diff {
+	oldMap := map[string]dialect.Table{}
+	for _, t := range oldTables {
+		oldMap[t.Name] = t
+	}
+	newMap := map[string]dialect.Table{}
+	for _, t := range newTables {
+		newMap[t.Name] = t
+	}
+
+	d := &Diff{}
+	for _, name := range sortedTableNames(newMap) {
+		newTable := newMap[name]
+		oldTable, ok := oldMap[name]
+		if !ok {
+			d.AddedTables = append(d.AddedTables, newTable)
+			continue
+		}
+		if td := compareTable(oldTable, newTable); !td.IsEmpty() {
+			d.ModifiedTables = append(d.ModifiedTables, td)
+		}
+	}
+	for _, name := range sortedTableNames(oldMap) {
+		if _, ok := newMap[name]; !ok {
+			d.DroppedTables = append(d.DroppedTables, oldMap[name])
+		}
+	}
+	return d
+}
+
+// IsEmpty reports whether there are no changes at all.
+func (d *Diff) IsEmpty() bool {
+	return len(d.AddedTables) == 0 && len(d.DroppedTables) == 0 && len(d.ModifiedTables) == 0
+}
+
+// IsEmpty reports whether the table has no changes.
+func (t *TableDiff) IsEmpty() bool {
+	return len(t.AddedColumns) == 0 &&
+		len(t.DroppedColumns) == 0 &&
+		len(t.ModifiedColumns) == 0 &&
+		!t.PrimaryKeyChanged &&
+		len(t.AddedIndexes) == 0 &&
+		len(t.DroppedIndexes) == 0 &&
+		len(t.AddedForeignKeys) == 0 &&
+		len(t.DroppedForeignKeys) == 0
+}
+
+func compareTable(oldTable, newTable dialect.Table) *TableDiff {
+	td := &TableDiff{
+		Old:                oldTable,
+		New:                newTable,
+		AddedForeignKeys:   map[string]dialect.ForeignKey{},
+		DroppedForeignKeys: map[string]dialect.ForeignKey{},
+	}
+
+	// Columns
+	oldFields := map[string]dialect.Field{}
+	for _, f := range oldTable.Fields {
+		oldFields[f.Name] = f
+	}
+	newFields := map[string]struct{}{}
+	for _, f := range newTable.Fields {
+		newFields[f.Name] = struct{}{}
+		old, ok := oldFields[f.Name]
+		if !ok {
+			td.AddedColumns = append(td.AddedColumns, f)
+			continue
+		}
+		if !EqualField(old, f) {
+			td.ModifiedColumns = append(td.ModifiedColumns, &ColumnDiff{Old: old, New: f})
+		}
+	}
+	for _, f := range oldTable.Fields {
+		if _, ok := newFields[f.Name]; !ok {
+			td.DroppedColumns = append(td.DroppedColumns, f)
+		}
+	}
+
+	// Primary keys
+	td.PrimaryKeyChanged = strings.Join(oldTable.PrimaryKeys, ",") != strings.Join(newTable.PrimaryKeys, ",")
+
+	// Indexes
+	oldIndexes := map[string]dialect.Index{}
+	for _, idx := range oldTable.Indexes {
+		oldIndexes[idx.Name] = idx
+	}
+	newIndexes := map[string]dialect.Index{}
+	for _, idx := range newTable.Indexes {
+		newIndexes[idx.Name] = idx
+		old, ok := oldIndexes[idx.Name]
+		if !ok {
+			td.AddedIndexes = append(td.AddedIndexes, idx)
+			continue
+		}
+		if !EqualIndex(old, idx) {
+			td.DroppedIndexes = append(td.DroppedIndexes, old)
+			td.AddedIndexes = append(td.AddedIndexes, idx)
+		}
+	}
+	for _, idx := range oldTable.Indexes {
+		if _, ok := newIndexes[idx.Name]; !ok {
+			td.DroppedIndexes = append(td.DroppedIndexes, idx)
+		}
+	}
+
+	// Foreign keys
+	oldFKs := NormalizeForeignKeys(oldTable.ForeignKeys)
+	newFKs := NormalizeForeignKeys(newTable.ForeignKeys)
+	for column, reference := range newFKs {
+		if old, ok := oldFKs[column]; !ok || old != reference {
+			td.AddedForeignKeys[column] = reference
+		}
+	}
+	for column, reference := range oldFKs {
+		if n, ok := newFKs[column]; !ok || n != reference {
+			td.DroppedForeignKeys[column] = reference
+		}
+	}
+	return td
+}
+
+// EqualField reports whether two columns have the same definition.
+func EqualField(a, b dialect.Field) bool {
+	return a.Name == b.Name &&
+		strings.EqualFold(a.Type, b.Type) &&
+		a.Nullable == b.Nullable &&
+		a.Default == b.Default &&
+		a.AutoIncrement == b.AutoIncrement &&
+		strings.EqualFold(a.Extra, b.Extra) &&
+		a.Comment == b.Comment
+}
+
+// EqualIndex reports whether two indexes have the same definition.
+func EqualIndex(a, b dialect.Index) bool {
+	return a.Name == b.Name && a.Unique == b.Unique && strings.Join(a.Columns, ",") == strings.Join(b.Columns, ",")
+}
+
+// NormalizeForeignKeys converts the field names and references of foreign keys to column and table names,
+// since tables built from structs use Go names while the dialects convert them when writing SQL.
+func NormalizeForeignKeys(fks map[string]dialect.ForeignKey) map[string]dialect.ForeignKey {
+	normalized := make(map[string]dialect.ForeignKey, len(fks))
+	for name, reference := range fks {
+		normalized[stringutil.ToSnakeCase(name)] = dialect.ForeignKey{
+			Table:  stringutil.ToSnakeCase(reference.Table),
+			Column: stringutil.ToSnakeCase(reference.Column),
+		}
+	}
+	return normalized
+}
+
+func sortedTableNames(m map[string]dialect.Table) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
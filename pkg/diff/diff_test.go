@@ -0,0 +1,130 @@
+package diff
+
+import (
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	id := dialect.Field{Table: "user", Name: "id", Type: "BIGINT", AutoIncrement: true}
+	name := dialect.Field{Table: "user", Name: "name", Type: "VARCHAR(255)"}
+	user := dialect.Table{Name: "user", Fields: []dialect.Field{id, name}, PrimaryKeys: []string{"id"}}
+
+	tests := []struct {
+		name     string
+		old      []dialect.Table
+		new      []dialect.Table
+		added    []string
+		dropped  []string
+		modified []*TableDiff
+	}{
+		{
+			name: "no changes",
+			old:  []dialect.Table{user},
+			new:  []dialect.Table{user},
+		},
+		{
+			name:    "tables added and dropped in name order",
+			old:     []dialect.Table{{Name: "b"}, {Name: "a"}},
+			new:     []dialect.Table{{Name: "d"}, {Name: "c"}},
+			added:   []string{"c", "d"},
+			dropped: []string{"a", "b"},
+		},
+		{
+			name: "columns added, dropped and modified",
+			old:  []dialect.Table{user},
+			new: []dialect.Table{{
+				Name:        "user",
+				Fields:      []dialect.Field{id, {Table: "user", Name: "name", Type: "VARCHAR(255)", Nullable: true}, {Table: "user", Name: "age", Type: "INT"}},
+				PrimaryKeys: []string{"id"},
+			}},
+			modified: []*TableDiff{{
+				AddedColumns:    []dialect.Field{{Table: "user", Name: "age", Type: "INT"}},
+				ModifiedColumns: []*ColumnDiff{{Old: name, New: dialect.Field{Table: "user", Name: "name", Type: "VARCHAR(255)", Nullable: true}}},
+			}},
+		},
+		{
+			name: "column type compared regardless of case",
+			old:  []dialect.Table{user},
+			new: []dialect.Table{{
+				Name:        "user",
+				Fields:      []dialect.Field{{Table: "user", Name: "id", Type: "bigint", AutoIncrement: true}, name},
+				PrimaryKeys: []string{"id"},
+			}},
+		},
+		{
+			name: "primary key and index changed",
+			old: []dialect.Table{{
+				Name:        "user",
+				Fields:      []dialect.Field{id, name},
+				PrimaryKeys: []string{"id"},
+				Indexes:     []dialect.Index{{Table: "user", Name: "user_name_idx", Columns: []string{"name"}}},
+			}},
+			new: []dialect.Table{{
+				Name:        "user",
+				Fields:      []dialect.Field{id, name},
+				PrimaryKeys: []string{"id", "name"},
+				Indexes:     []dialect.Index{{Table: "user", Name: "user_name_idx", Columns: []string{"name"}, Unique: true}},
+			}},
+			modified: []*TableDiff{{
+				PrimaryKeyChanged: true,
+				AddedIndexes:      []dialect.Index{{Table: "user", Name: "user_name_idx", Columns: []string{"name"}, Unique: true}},
+				DroppedIndexes:    []dialect.Index{{Table: "user", Name: "user_name_idx", Columns: []string{"name"}}},
+			}},
+		},
+		{
+			name: "foreign keys compared by column and table names",
+			old: []dialect.Table{{
+				Name:        "post",
+				ForeignKeys: map[string]dialect.ForeignKey{"AuthorID": {Table: "User", Column: "ID"}, "editor_id": {Table: "user", Column: "id"}},
+			}},
+			new: []dialect.Table{{
+				Name:        "post",
+				ForeignKeys: map[string]dialect.ForeignKey{"author_id": {Table: "user", Column: "id"}, "editor_id": {Table: "admin", Column: "id"}},
+			}},
+			modified: []*TableDiff{{
+				AddedForeignKeys:   map[string]dialect.ForeignKey{"editor_id": {Table: "admin", Column: "id"}},
+				DroppedForeignKeys: map[string]dialect.ForeignKey{"editor_id": {Table: "user", Column: "id"}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Compare(tt.old, tt.new)
+			if got := tableNames(d.AddedTables); !reflect.DeepEqual(got, tt.added) {
+				t.Errorf("AddedTables = %v, want %v", got, tt.added)
+			}
+			if got := tableNames(d.DroppedTables); !reflect.DeepEqual(got, tt.dropped) {
+				t.Errorf("DroppedTables = %v, want %v", got, tt.dropped)
+			}
+			if len(d.ModifiedTables) != len(tt.modified) {
+				t.Fatalf("ModifiedTables = %d tables, want %d", len(d.ModifiedTables), len(tt.modified))
+			}
+			for i, want := range tt.modified {
+				got := *d.ModifiedTables[i]
+				// The tables themselves are not compared, only the changes found in them.
+				got.Old, got.New = dialect.Table{}, dialect.Table{}
+				if len(got.AddedForeignKeys) == 0 && want.AddedForeignKeys == nil {
+					got.AddedForeignKeys = nil
+				}
+				if len(got.DroppedForeignKeys) == 0 && want.DroppedForeignKeys == nil {
+					got.DroppedForeignKeys = nil
+				}
+				if !reflect.DeepEqual(&got, want) {
+					t.Errorf("ModifiedTables[%d] = %#v, want %#v", i, &got, want)
+				}
+			}
+			if d.IsEmpty() != (tt.added == nil && tt.dropped == nil && tt.modified == nil) {
+				t.Errorf("IsEmpty() = %v", d.IsEmpty())
+			}
+		})
+	}
+}
+
+func tableNames(tables []dialect.Table) (names []string) {
+	for _, t := range tables {
+		names = append(names, t.Name)
+	}
+	return
+}
@@ -0,0 +1,129 @@
+package drift
+
+import (
+	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/diff"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Detect compares the tables declared by structs with the tables of a live database.
+// In the result the live database is the old side and the structs are the new side,
+// so added tables and columns are missing in the database and dropped ones are only in the database.
+// Tables named in ignore, like the bookkeeping table of the migration runner, are skipped.
+func Detect(model, live []dialect.Table, ignore ...string) *diff.Diff {
+	ignored := map[string]struct{}{}
+	for _, name := range ignore {
+		ignored[name] = struct{}{}
+	}
+	tables := make([]dialect.Table, 0, len(live))
+	for _, t := range live {
+		if _, ok := ignored[t.Name]; ok {
+			continue
+		}
+		fields := make([]dialect.Field, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			if !dialect.IsTimestampColumn(f.Name) {
+				fields = append(fields, f)
+			}
+		}
+		t.Fields = fields
+		tables = append(tables, t)
+	}
+	return diff.Compare(tables, model)
+}
+
+// Report writes every difference found by Detect as one line.
+func Report(w io.Writer, d *diff.Diff) (err error) {
+	var lines []string
+	for _, t := range d.AddedTables {
+		lines = append(lines, fmt.Sprintf("table %s: missing in database", t.Name))
+	}
+	for _, t := range d.DroppedTables {
+		lines = append(lines, fmt.Sprintf("table %s: not declared by any struct", t.Name))
+	}
+	for _, td := range d.ModifiedTables {
+		lines = append(lines, tableLines(td)...)
+	}
+	for _, line := range lines {
+		if _, err = fmt.Fprintln(w, line); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func tableLines(td *diff.TableDiff) (lines []string) {
+	name := td.New.Name
+	for _, f := range td.AddedColumns {
+		lines = append(lines, fmt.Sprintf("column %s.%s: missing in database", name, f.Name))
+	}
+	for _, f := range td.DroppedColumns {
+		lines = append(lines, fmt.Sprintf("column %s.%s: not declared by any struct", name, f.Name))
+	}
+	for _, cd := range td.ModifiedColumns {
+		column := fmt.Sprintf("column %s.%s", name, cd.New.Name)
+		if !strings.EqualFold(cd.Old.Type, cd.New.Type) {
+			lines = append(lines, fmt.Sprintf("%s: type is %s in database, %s in structs", column, cd.Old.Type, cd.New.Type))
+		}
+		if cd.Old.Nullable != cd.New.Nullable {
+			lines = append(lines, fmt.Sprintf("%s: %s in database, %s in structs", column, nullability(cd.Old), nullability(cd.New)))
+		}
+		if cd.Old.Default != cd.New.Default {
+			lines = append(lines, fmt.Sprintf("%s: default is %s in database, %s in structs", column, orNone(cd.Old.Default), orNone(cd.New.Default)))
+		}
+		if cd.Old.AutoIncrement != cd.New.AutoIncrement {
+			lines = append(lines, fmt.Sprintf("%s: auto increment is %t in database, %t in structs", column, cd.Old.AutoIncrement, cd.New.AutoIncrement))
+		}
+		if !strings.EqualFold(cd.Old.Extra, cd.New.Extra) {
+			lines = append(lines, fmt.Sprintf("%s: extra is %s in database, %s in structs", column, orNone(cd.Old.Extra), orNone(cd.New.Extra)))
+		}
+		if cd.Old.Comment != cd.New.Comment {
+			lines = append(lines, fmt.Sprintf("%s: comment is %q in database, %q in structs", column, cd.Old.Comment, cd.New.Comment))
+		}
+	}
+	if td.PrimaryKeyChanged {
+		lines = append(lines, fmt.Sprintf("table %s: primary key is (%s) in database, (%s) in structs",
+			name, strings.Join(td.Old.PrimaryKeys, ", "), strings.Join(td.New.PrimaryKeys, ", ")))
+	}
+	for _, idx := range td.AddedIndexes {
+		lines = append(lines, fmt.Sprintf("index %s on %s(%s): missing in database", idx.Name, name, strings.Join(idx.Columns, ", ")))
+	}
+	for _, idx := range td.DroppedIndexes {
+		lines = append(lines, fmt.Sprintf("index %s on %s(%s): not declared by any struct", idx.Name, name, strings.Join(idx.Columns, ", ")))
+	}
+	for _, column := range sortedColumns(td.AddedForeignKeys) {
+		ref := td.AddedForeignKeys[column]
+		lines = append(lines, fmt.Sprintf("foreign key %s.%s -> %s.%s: missing in database", name, column, ref.Table, ref.Column))
+	}
+	for _, column := range sortedColumns(td.DroppedForeignKeys) {
+		ref := td.DroppedForeignKeys[column]
+		lines = append(lines, fmt.Sprintf("foreign key %s.%s -> %s.%s: not declared by any struct", name, column, ref.Table, ref.Column))
+	}
+	return
+}
+
+func nullability(f dialect.Field) string {
+	if f.Nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func sortedColumns(fks map[string]dialect.ForeignKey) []string {
+	columns := make([]string, 0, len(fks))
+	for column := range fks {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
@@ -0,0 +1,75 @@
+package drift
+
+import (
+	"bytes"
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"testing"
+)
+
+func TestDetectReport(t *testing.T) {
+	id := dialect.Field{Table: "user", Name: "id", Type: "BIGINT", AutoIncrement: true}
+	name := dialect.Field{Table: "user", Name: "name", Type: "VARCHAR(255)"}
+	createdAt := dialect.Field{Table: "user", Name: "created_at", Type: "DATETIME", Default: "CURRENT_TIMESTAMP", Nullable: true}
+	post := dialect.Table{Name: "post", Fields: []dialect.Field{{Table: "post", Name: "id", Type: "BIGINT"}}, PrimaryKeys: []string{"id"}}
+	migrations := dialect.Table{Name: "schema_migrations", Fields: []dialect.Field{{Table: "schema_migrations", Name: "version", Type: "BIGINT"}}}
+
+	tests := []struct {
+		name  string
+		model []dialect.Table
+		live  []dialect.Table
+		want  string
+	}{
+		{
+			name:  "no drift",
+			model: []dialect.Table{{Name: "user", Fields: []dialect.Field{id, name}, PrimaryKeys: []string{"id"}}},
+			live:  []dialect.Table{{Name: "user", Fields: []dialect.Field{id, name, createdAt}, PrimaryKeys: []string{"id"}}, migrations},
+		},
+		{
+			name:  "tables",
+			model: []dialect.Table{post},
+			live:  []dialect.Table{{Name: "user", Fields: []dialect.Field{id}, PrimaryKeys: []string{"id"}}},
+			want: "table post: missing in database\n" +
+				"table user: not declared by any struct\n",
+		},
+		{
+			name: "columns, keys and indexes",
+			model: []dialect.Table{{
+				Name: "user",
+				Fields: []dialect.Field{
+					id,
+					{Table: "user", Name: "name", Type: "VARCHAR(80)", Nullable: true, Default: "guest", Comment: "login name"},
+					{Table: "user", Name: "post_id", Type: "BIGINT"},
+				},
+				PrimaryKeys: []string{"id"},
+				Indexes:     []dialect.Index{{Table: "user", Name: "user_name_idx", Columns: []string{"name"}}},
+				ForeignKeys: map[string]dialect.ForeignKey{"post_id": {Table: "post", Column: "id"}},
+			}, post},
+			live: []dialect.Table{{
+				Name:        "user",
+				Fields:      []dialect.Field{{Table: "user", Name: "id", Type: "BIGINT"}, name, {Table: "user", Name: "bio", Type: "TEXT"}},
+				PrimaryKeys: []string{"name"},
+			}, post},
+			want: "column user.post_id: missing in database\n" +
+				"column user.bio: not declared by any struct\n" +
+				"column user.id: auto increment is false in database, true in structs\n" +
+				"column user.name: type is VARCHAR(255) in database, VARCHAR(80) in structs\n" +
+				"column user.name: NOT NULL in database, NULL in structs\n" +
+				"column user.name: default is none in database, guest in structs\n" +
+				"column user.name: comment is \"\" in database, \"login name\" in structs\n" +
+				"table user: primary key is (name) in database, (id) in structs\n" +
+				"index user_name_idx on user(name): missing in database\n" +
+				"foreign key user.post_id -> post.id: missing in database\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := Report(&b, Detect(tt.model, tt.live, "schema_migrations")); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Report() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
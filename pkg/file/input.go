@@ -44,6 +44,9 @@ func isGoTest(filename string) bool {
 func GetFiles(fileSystem *afero.Fs, dir string) (filenames []string, err error) {
 	err = afero.Walk(*fileSystem, dir,
 		func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
 			if !info.IsDir() {
 				if !isGoFile(path) {
 					return nil
@@ -0,0 +1,55 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/spf13/afero"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ImportPath returns the import path of the package in dir, from the module declared in the nearest go.mod above it.
+func ImportPath(fileSystem *afero.Fs, dir string) (importPath string, err error) {
+	var rel []string
+	for current := filepath.Clean(dir); ; current = filepath.Dir(current) {
+		b, rErr := afero.ReadFile(*fileSystem, filepath.Join(current, "go.mod"))
+		if rErr == nil {
+			module := modulePath(b)
+			if module == "" {
+				return "", fmt.Errorf("auto-table: no module declared in %s", filepath.Join(current, "go.mod"))
+			}
+			for i := len(rel) - 1; i >= 0; i-- {
+				module = path.Join(module, rel[i])
+			}
+			return module, nil
+		}
+		if filepath.Dir(current) == current {
+			return "", fmt.Errorf("auto-table: %s is not in a Go module", dir)
+		}
+		rel = append(rel, filepath.Base(current))
+	}
+}
+
+// modulePath reads the module directive of a go.mod file.
+func modulePath(gomod []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(gomod))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		module := fields[1]
+		if unquoted, err := strconv.Unquote(module); err == nil {
+			module = unquoted
+		}
+		return module
+	}
+	return ""
+}
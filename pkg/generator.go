@@ -2,8 +2,12 @@ package pkg
 
 import (
 	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/diagnostic"
 	"github.com/hourglasshoro/auto-table/pkg/dialect"
 	"github.com/hourglasshoro/auto-table/pkg/migration"
+	"github.com/hourglasshoro/auto-table/pkg/query"
+	"github.com/hourglasshoro/auto-table/pkg/repository"
+	"github.com/hourglasshoro/auto-table/pkg/snapshot"
 	"github.com/hourglasshoro/auto-table/pkg/sql"
 	"github.com/spf13/afero"
 )
@@ -13,13 +17,17 @@ type Generator struct {
 	AutoID        bool // Flag to automatically set id as primary key
 	Marker        string
 	TagMaker      string
+	Format        migration.Format     // Layout of the migration files, golang-migrate if nil
+	Versioning    migration.Versioning // Versions of the migration files
 	SQLMap        map[string]*sql.SQL
 	DependencyMap map[string]map[string]struct{}
+	Diagnostics   diagnostic.List // Fields left out by CreateSQL and cycles of tables found by WriteFile, with their positions
 }
 
-func NewGenerator(marker string) *Generator {
+// NewGenerator creates a generator of the statements of the dialect, like one returned by dialect.New.
+func NewGenerator(d dialect.Dialect, marker string) *Generator {
 	return &Generator{
-		Dialect:  dialect.NewMySQL(),
+		Dialect:  d,
 		AutoID:   true,
 		Marker:   fmt.Sprintf("+%s", marker),
 		TagMaker: marker,
@@ -27,7 +35,8 @@ func NewGenerator(marker string) *Generator {
 }
 
 func (g *Generator) CreateSQL(filenames []string) (sqlMap map[string]*sql.SQL, err error) {
-	sqlMap, dependencyMap, err := sql.CreateSQL(g.Dialect, g.AutoID, g.Marker, g.TagMaker, filenames)
+	sqlMap, dependencyMap, diags, err := sql.CreateSQL(g.Dialect, g.AutoID, g.Marker, g.TagMaker, filenames)
+	g.Diagnostics = diags
 	g.SQLMap = sqlMap
 	g.DependencyMap = dependencyMap
 	return
@@ -35,22 +44,49 @@ func (g *Generator) CreateSQL(filenames []string) (sqlMap map[string]*sql.SQL, e
 
 func (g *Generator) WriteFile(fs *afero.Fs, outPutDir string, f func(content string, filename string) error) (err error) {
 	// TODO: DO refactor
-	m := migration.NewMigrate(g.SQLMap, g.DependencyMap, outPutDir)
-	for _, filename := range m.Order {
-
-		// Up
-		output := fmt.Sprintf("%s/%s", m.OutputDir, m.Map[filename].Up.File)
-		wErr := f(m.Map[filename].Up.SQL, output)
-		if wErr != nil {
-			err = wErr
+	m := migration.NewMigrate(g.Dialect, g.SQLMap, g.DependencyMap, outPutDir, g.Versioning)
+	m.Format = g.Format
+	g.Diagnostics = append(g.Diagnostics, m.Diagnostics...)
+	for _, key := range m.Order {
+		for _, elm := range m.Files(key) {
+			err = f(elm.SQL, fmt.Sprintf("%s/%s", m.OutputDir, elm.File))
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	// Snapshot
+	b, err := snapshot.New(g.SQLMap, "").Marshal()
+	if err != nil {
+		return
+	}
+	err = f(string(b), fmt.Sprintf("%s/%s", m.OutputDir, snapshot.DefaultFilename))
+	return
+}
+
+// WriteQueries passes the CRUD statements of each table as a named query file to f.
+func (g *Generator) WriteQueries(outputDir string, f func(content string, filename string) error) (err error) {
+	q := query.NewQueries(g.SQLMap, outputDir)
+	for _, tableName := range q.Order {
+		err = f(q.Map[tableName].String(), fmt.Sprintf("%s/%s", q.OutputDir, q.Map[tableName].File))
+		if err != nil {
 			return
 		}
+	}
+	return
+}
 
-		// Down
-		output = fmt.Sprintf("%s/%s", m.OutputDir, m.Map[filename].Down.File)
-		wErr = f(m.Map[filename].Down.SQL, output)
-		if wErr != nil {
-			err = wErr
+// WriteRepositories passes the Go repository of each table to f.
+// importPaths maps the files the structs are declared in to the import paths of their packages.
+func (g *Generator) WriteRepositories(outputDir string, importPaths map[string]string, f func(content string, filename string) error) (err error) {
+	r, err := repository.NewRepositories(g.Dialect, g.SQLMap, outputDir, importPaths)
+	if err != nil {
+		return
+	}
+	for _, filename := range r.Order {
+		err = f(string(r.Map[filename]), fmt.Sprintf("%s/%s", r.OutputDir, filename))
+		if err != nil {
 			return
 		}
 	}
@@ -2,9 +2,14 @@ package migration
 
 import (
 	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/diagnostic"
+	d "github.com/hourglasshoro/auto-table/pkg/dialect"
 	sql "github.com/hourglasshoro/auto-table/pkg/sql"
 	"github.com/spf13/afero"
+	"go/token"
 	"log"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -14,75 +19,187 @@ type MigrateElm struct {
 }
 
 type Migrate struct {
-	Up   *MigrateElm
-	Down *MigrateElm
+	Version int64
+	Digits  int    // Minimum number of digits of the version in the file names, padded with zeros
+	Name    string // Description following the version in the file names, like add_user_table
+	Up      *MigrateElm
+	Down    *MigrateElm
 }
 
 type Migrates struct {
-	Map       map[string]*Migrate // map[tableName]Migrate
-	Order     []string            // []tableName
-	OutputDir string
+	Map         map[string]*Migrate // map[tableName]Migrate, keyed by the migration name for foreign keys closing cycles, or map[fileName]Migrate for migrations read from a directory
+	Order       []string            // []key
+	OutputDir   string
+	Format      Format          // Layout of the written files, golang-migrate if nil
+	Diagnostics diagnostic.List // Cycles of tables referencing each other, at the structs of the tables
 }
 
-func NewMigrate(sqls map[string]*sql.SQL, dependencyMap map[string]map[string]struct{}, output string) *Migrates {
+// SequenceDigits is the number of digits sequence numbers are padded to, like migrate create -seq does.
+const SequenceDigits = 6
+
+// Versioning decides the versions of the migrations.
+// Versions are Unix times by default, which makes the files differ on every run unless Base is set.
+type Versioning struct {
+	Base     int64 // Version of the first migration, the current Unix time or 1 for sequences if zero
+	Sequence bool  // Number the migrations 1, 2, 3 and so on instead of by Unix time
+}
+
+// First returns the version of the first migration written after the latest version already written.
+func (v Versioning) First(latest int64) int64 {
+	first := v.Base
+	if first == 0 {
+		first = 1
+		if !v.Sequence {
+			first = time.Now().Unix()
+		}
+	}
+	if latest >= first {
+		first = latest + 1
+	}
+	return first
+}
+
+func (v Versioning) digits() int {
+	if v.Sequence {
+		return SequenceDigits
+	}
+	return 0
+}
+
+// NewMigrate creates a migration for each table, referenced tables first and otherwise in name order.
+// Foreign keys closing a cycle of tables referencing each other are added by migrations of their own after all the tables are created,
+// if the dialect can add foreign keys to existing tables.
+func NewMigrate(dialect d.Dialect, sqls map[string]*sql.SQL, dependencyMap map[string]map[string]struct{}, output string, versioning Versioning) *Migrates {
+	migrates := newMigrates(output)
+	version := versioning.First(0)
+	add := func(key string, name string, up string, down string) {
+		migrates.add(key, version, versioning.digits(), name, up, down)
+		version++
+	}
+
+	positions := map[string]token.Position{} // map[tableName]position of the struct
+	for tableName, s := range sqls {
+		positions[tableName] = s.Source.Pos
+	}
+	deferred, cycles := breakCycles(dependencyMap)
+	modifier, ok := dialect.(d.ForeignKeyModifier)
+	if ok {
+		reportCycles(&migrates.Diagnostics, cycles, positions, func(from string, to string) string {
+			return fmt.Sprintf("the foreign keys of %s referencing %s are added after the tables are created", from, to)
+		})
+	} else {
+		// The foreign keys are created with the tables, which the dialect allows before the referenced tables exist.
+		deferred = nil
+		reportCycles(&migrates.Diagnostics, cycles, positions, func(from string, to string) string {
+			return fmt.Sprintf("the foreign keys of %s referencing %s are created before %s exists, as the dialect cannot add them to existing tables", from, to, to)
+		})
+	}
+	deferredFKs := map[string]map[string]d.ForeignKey{} // map[tableName]map[columnName]reference
+	order := sortByDependency(dependencyMap)
+	for _, tableName := range order {
+		s := sqls[tableName]
+		up := s.Table.Create
+		if references, ok := deferred[tableName]; ok {
+			var table d.Table
+			table, deferredFKs[tableName] = withoutForeignKeys(s.Schema, references)
+			up = strings.Join(sql.CreateTableSQL(dialect, table), "\n")
+		}
+		add(tableName, fmt.Sprintf("add_%s_table", tableName), up, s.Table.Drop)
+	}
+	for _, tableName := range order {
+		if fks, ok := deferredFKs[tableName]; ok {
+			up, down := addForeignKeySQL(modifier, tableName, fks)
+			add(foreignKeysName("add", tableName), foreignKeysName("add", tableName), strings.Join(up, "\n"), strings.Join(down, "\n"))
+		}
+	}
+	return migrates
+}
+
+func newMigrates(output string) *Migrates {
 	migrates := new(Migrates)
 	migrates.Map = map[string]*Migrate{}
 	migrates.Order = []string{}
 	migrates.OutputDir = output
-	t := time.Now()
-	var deleteList []string
+	return migrates
+}
+
+// add appends a migration to the end of the order.
+func (m *Migrates) add(key string, version int64, digits int, name string, up string, down string) {
+	m.Order = append(m.Order, key)
+	migrate := &Migrate{
+		Version: version,
+		Digits:  digits,
+		Name:    name,
+	}
+	migrate.Up = &MigrateElm{
+		File: fmt.Sprintf("%s_%s%s", migrate.version(), name, upSuffix),
+		SQL:  up,
+	}
+	migrate.Down = &MigrateElm{
+		File: fmt.Sprintf("%s_%s%s", migrate.version(), name, downSuffix),
+		SQL:  down,
+	}
+	m.Map[key] = migrate
+}
+
+// version formats the version for the file names.
+func (m *Migrate) version() string {
+	return fmt.Sprintf("%0*d", m.Digits, m.Version)
+}
+
+// sortByDependency orders the tables so that every table comes after the tables it depends on.
+// Tables whose dependencies are met at the same time are ordered by name, so the order is the same on every run.
+func sortByDependency(dependencyMap map[string]map[string]struct{}) (order []string) {
 	for len(dependencyMap) > 0 {
+		var ready []string
 		for tableName, dependency := range dependencyMap {
 			if len(dependency) == 0 {
-				migrates.Order = append(migrates.Order, tableName)
-				timestamp := t.Unix()
-				t = t.Add(time.Second * 1)
-				migrates.Map[tableName] = &Migrate{
-					Up: &MigrateElm{
-						File: fmt.Sprintf("%d_add_%s_table.up.sql", timestamp, tableName),
-						SQL:  sqls[tableName].Table.Create,
-					},
-					Down: &MigrateElm{
-						File: fmt.Sprintf("%d_add_%s_table.down.sql", timestamp, tableName),
-						SQL:  sqls[tableName].Table.Drop,
-					},
-				}
-				deleteList = append(deleteList, tableName)
-				delete(dependencyMap, tableName)
+				ready = append(ready, tableName)
+			}
+		}
+		if len(ready) == 0 {
+			// Only tables depending on tables that are not in the map are left.
+			for tableName := range dependencyMap {
+				ready = append(ready, tableName)
 			}
 		}
+		sort.Strings(ready)
+		for _, tableName := range ready {
+			order = append(order, tableName)
+			delete(dependencyMap, tableName)
+		}
 
 		for _, dependency := range dependencyMap {
-			for _, d := range deleteList {
+			for _, d := range ready {
 				delete(dependency, d)
 			}
 		}
 	}
-	return migrates
+	return
 }
 
 func (m *Migrates) WriteFile(fs *afero.Fs) (err error) {
-	for _, filename := range m.Order {
-
-		// Up
-		output := fmt.Sprintf("%s/%s", m.OutputDir, m.Map[filename].Up.File)
-		wErr := afero.WriteFile(*fs, output, []byte(m.Map[filename].Up.SQL), 0644)
-		if wErr != nil {
-			err = wErr
-			return
-		}
-
-		// Down
-		output = fmt.Sprintf("%s/%s", m.OutputDir, m.Map[filename].Down.File)
-		wErr = afero.WriteFile(*fs, output, []byte(m.Map[filename].Down.SQL), 0644)
-		if wErr != nil {
-			err = wErr
-			return
+	for _, key := range m.Order {
+		for _, elm := range m.Files(key) {
+			output := fmt.Sprintf("%s/%s", m.OutputDir, elm.File)
+			err = afero.WriteFile(*fs, output, []byte(elm.SQL), 0644)
+			if err != nil {
+				return
+			}
 		}
 	}
 	return
 }
 
+// Files returns the files of a migration in the format of the migrations.
+func (m *Migrates) Files(key string) []*MigrateElm {
+	format := m.Format
+	if format == nil {
+		format = golangMigrate{}
+	}
+	return format.Files(m.Map[key])
+}
+
 func (m *Migrates) Print(table string) {
 	for _, filename := range m.Order {
 		if filename == table || table == "" {
@@ -0,0 +1,140 @@
+package migration
+
+import (
+	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/diagnostic"
+	d "github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/naoina/go-stringutil"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// breakCycles removes the dependencies that close a cycle of tables referencing each other, so that the tables can be ordered.
+// The removed dependencies are returned as map[tableName]referencedTableNames, together with the cycles they close as chains of tables.
+// A table referencing itself is no cycle, as its foreign key can be created with the table.
+func breakCycles(dependencyMap map[string]map[string]struct{}) (deferred map[string]map[string]struct{}, cycles [][]string) {
+	deferred = map[string]map[string]struct{}{}
+	for {
+		cycle := findCycle(dependencyMap)
+		if cycle == nil {
+			return
+		}
+		from, to := cycle[len(cycle)-2], cycle[len(cycle)-1]
+		delete(dependencyMap[from], to)
+		if from == to {
+			continue
+		}
+		cycles = append(cycles, cycle)
+		if deferred[from] == nil {
+			deferred[from] = map[string]struct{}{}
+		}
+		deferred[from][to] = struct{}{}
+	}
+}
+
+// reportCycles warns of each cycle at the struct of the table whose foreign keys break it.
+// message tells what happens to the foreign keys of the table from referencing the table to.
+func reportCycles(diags *diagnostic.List, cycles [][]string, positions map[string]token.Position, message func(from string, to string) string) {
+	for _, cycle := range cycles {
+		from, to := cycle[len(cycle)-2], cycle[len(cycle)-1]
+		diags.Report(positions[from], diagnostic.Warnf("tables %s reference each other, %s", strings.Join(cycle, " -> "), message(from, to)))
+	}
+}
+
+// findCycle returns a chain of tables each depending on the next, which ends with the table it starts with, like group -> user -> group.
+// It returns nil if no table depends on itself.
+func findCycle(dependencyMap map[string]map[string]struct{}) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{} // map[tableName]state
+	var path []string
+	var visit func(tableName string) []string
+	visit = func(tableName string) []string {
+		state[tableName] = visiting
+		path = append(path, tableName)
+		for _, dependency := range sortedNames(dependencyMap[tableName]) {
+			switch state[dependency] {
+			case visiting:
+				for i, name := range path {
+					if name == dependency {
+						return append(append([]string{}, path[i:]...), dependency)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[tableName] = visited
+		return nil
+	}
+
+	tableNames := make([]string, 0, len(dependencyMap))
+	for tableName := range dependencyMap {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+	for _, tableName := range tableNames {
+		if state[tableName] != unvisited {
+			continue
+		}
+		if cycle := visit(tableName); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// withoutForeignKeys returns the table without its foreign keys referencing the tables, and the removed foreign keys as map[columnName]reference.
+func withoutForeignKeys(table d.Table, references map[string]struct{}) (d.Table, map[string]d.ForeignKey) {
+	removed := map[string]d.ForeignKey{}
+	kept := map[string]d.ForeignKey{}
+	for name, reference := range table.ForeignKeys {
+		if _, ok := references[stringutil.ToSnakeCase(reference.Table)]; ok {
+			removed[stringutil.ToSnakeCase(name)] = d.ForeignKey{
+				Table:  stringutil.ToSnakeCase(reference.Table),
+				Column: stringutil.ToSnakeCase(reference.Column),
+			}
+			continue
+		}
+		kept[name] = reference
+	}
+	table.ForeignKeys = kept
+	return table, removed
+}
+
+// addForeignKeySQL generates the statements adding the foreign keys to the table, and the statements dropping them again.
+func addForeignKeySQL(modifier d.ForeignKeyModifier, tableName string, fks map[string]d.ForeignKey) (up []string, down []string) {
+	columns := make([]string, 0, len(fks))
+	for column := range fks {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		up = append(up, modifier.AddForeignKeySQL(tableName, column, fks[column])...)
+	}
+	for i := len(columns) - 1; i >= 0; i-- {
+		down = append(down, modifier.DropForeignKeySQL(tableName, columns[i])...)
+	}
+	return
+}
+
+// foreignKeysName names the migration adding or dropping the foreign keys closing a cycle, like add_user_foreign_keys.
+func foreignKeysName(action string, tableName string) string {
+	return fmt.Sprintf("%s_%s_foreign_keys", action, tableName)
+}
+
+func sortedNames(names map[string]struct{}) []string {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,112 @@
+package migration
+
+import (
+	"reflect"
+	"testing"
+)
+
+// dependencies makes a dependency map from table names to the names of the tables they depend on.
+func dependencies(m map[string][]string) map[string]map[string]struct{} {
+	dependencyMap := map[string]map[string]struct{}{}
+	for tableName, names := range m {
+		dependencyMap[tableName] = map[string]struct{}{}
+		for _, name := range names {
+			dependencyMap[tableName][name] = struct{}{}
+		}
+	}
+	return dependencyMap
+}
+
+func TestFindCycle(t *testing.T) {
+	tests := []struct {
+		name         string
+		dependencies map[string][]string
+		want         []string
+	}{
+		{
+			name:         "no cycle",
+			dependencies: map[string][]string{"post": {"user"}, "user": nil},
+			want:         nil,
+		},
+		{
+			name:         "two tables",
+			dependencies: map[string][]string{"group": {"user"}, "user": {"group"}},
+			want:         []string{"group", "user", "group"},
+		},
+		{
+			name:         "three tables",
+			dependencies: map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}, "d": {"a"}},
+			want:         []string{"a", "b", "c", "a"},
+		},
+		{
+			name:         "table referencing itself",
+			dependencies: map[string][]string{"category": {"category"}},
+			want:         []string{"category", "category"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findCycle(dependencies(tt.dependencies)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findCycle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakCyclesAndSortByDependency(t *testing.T) {
+	tests := []struct {
+		name         string
+		dependencies map[string][]string
+		deferred     map[string][]string
+		cycles       [][]string
+		order        []string
+	}{
+		{
+			name:         "no cycle",
+			dependencies: map[string][]string{"post": {"user"}, "user": nil, "tag": nil},
+			deferred:     map[string][]string{},
+			order:        []string{"tag", "user", "post"},
+		},
+		{
+			name:         "two tables",
+			dependencies: map[string][]string{"group": {"user"}, "user": {"group"}},
+			deferred:     map[string][]string{"user": {"group"}},
+			cycles:       [][]string{{"group", "user", "group"}},
+			order:        []string{"user", "group"},
+		},
+		{
+			name:         "table referencing itself",
+			dependencies: map[string][]string{"category": {"category"}, "post": {"category"}},
+			deferred:     map[string][]string{},
+			order:        []string{"category", "post"},
+		},
+		{
+			name:         "two cycles",
+			dependencies: map[string][]string{"a": {"b"}, "b": {"a"}, "c": {"d"}, "d": {"c", "a"}},
+			deferred:     map[string][]string{"b": {"a"}, "d": {"c"}},
+			cycles:       [][]string{{"a", "b", "a"}, {"c", "d", "c"}},
+			order:        []string{"b", "a", "d", "c"},
+		},
+		{
+			name:         "dependency on a table that is not generated",
+			dependencies: map[string][]string{"post": {"account"}},
+			deferred:     map[string][]string{},
+			order:        []string{"post"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependencyMap := dependencies(tt.dependencies)
+			deferred, cycles := breakCycles(dependencyMap)
+			if want := dependencies(tt.deferred); !reflect.DeepEqual(deferred, want) {
+				t.Errorf("breakCycles() = %v, want %v", deferred, want)
+			}
+			if !reflect.DeepEqual(cycles, tt.cycles) {
+				t.Errorf("breakCycles() cycles = %v, want %v", cycles, tt.cycles)
+			}
+			if got := sortByDependency(dependencyMap); !reflect.DeepEqual(got, tt.order) {
+				t.Errorf("sortByDependency() = %v, want %v", got, tt.order)
+			}
+		})
+	}
+}
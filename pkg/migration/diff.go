@@ -0,0 +1,229 @@
+package migration
+
+import (
+	"fmt"
+	d "github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/diff"
+	sql "github.com/hourglasshoro/auto-table/pkg/sql"
+	"github.com/naoina/go-stringutil"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// NewDiffMigrate creates the migrations that turn the old tables of the diff into the new ones.
+// New tables are created first, then existing tables are altered and finally removed tables are dropped.
+// Foreign keys closing a cycle of new or removed tables are added after, or dropped before, the tables by migrations of their own.
+// Versions follow latest, the largest version of the migrations already written.
+// The cycles are reported at the positions of the structs of the tables, given as map[tableName]position.
+func NewDiffMigrate(dialect d.Dialect, schemaDiff *diff.Diff, output string, versioning Versioning, latest int64, positions map[string]token.Position) *Migrates {
+	migrates := newMigrates(output)
+	version := versioning.First(latest)
+	add := func(key string, name string, up []string, down []string) {
+		migrates.add(key, version, versioning.digits(), name, strings.Join(up, "\n")+"\n", strings.Join(down, "\n")+"\n")
+		version++
+	}
+	modifier, canModify := dialect.(d.ForeignKeyModifier)
+
+	// New tables, referenced tables first
+	added := map[string]d.Table{}
+	for _, tbl := range schemaDiff.AddedTables {
+		added[tbl.Name] = tbl
+	}
+	dependencyMap := makeDependencyMap(schemaDiff.AddedTables)
+	deferred, cycles := breakCycles(dependencyMap)
+	reportCycles(&migrates.Diagnostics, cycles, positions, func(from string, to string) string {
+		if !canModify {
+			return fmt.Sprintf("the foreign keys of %s referencing %s are created before %s exists, as the dialect cannot add them to existing tables", from, to, to)
+		}
+		return fmt.Sprintf("the foreign keys of %s referencing %s are added after the tables are created", from, to)
+	})
+	deferredFKs := map[string]map[string]d.ForeignKey{} // map[tableName]map[columnName]reference
+	order := sortByDependency(dependencyMap)
+	for _, tableName := range order {
+		tbl := added[tableName]
+		create := tbl
+		if references, ok := deferred[tableName]; ok && canModify {
+			create, deferredFKs[tableName] = withoutForeignKeys(tbl, references)
+		}
+		add(tableName, fmt.Sprintf("add_%s_table", tableName), sql.CreateTableSQL(dialect, create), sql.DropTableSQL(dialect, tbl))
+	}
+	for _, tableName := range order {
+		if fks, ok := deferredFKs[tableName]; ok {
+			up, down := addForeignKeySQL(modifier, tableName, fks)
+			add(foreignKeysName("add", tableName), foreignKeysName("add", tableName), up, down)
+		}
+	}
+
+	for _, td := range schemaDiff.ModifiedTables {
+		up, down := alterTableSQL(dialect, td)
+		add(td.New.Name, fmt.Sprintf("alter_%s_table", td.New.Name), up, down)
+	}
+
+	// Removed tables, referencing tables first
+	dropped := map[string]d.Table{}
+	for _, tbl := range schemaDiff.DroppedTables {
+		dropped[tbl.Name] = tbl
+	}
+	dependencyMap = makeDependencyMap(schemaDiff.DroppedTables)
+	deferred, cycles = breakCycles(dependencyMap)
+	reportCycles(&migrates.Diagnostics, cycles, positions, func(from string, to string) string {
+		if !canModify {
+			return fmt.Sprintf("%s is dropped while %s references it, as the dialect cannot drop the foreign keys of existing tables", to, from)
+		}
+		return fmt.Sprintf("the foreign keys of %s referencing %s are dropped before the tables", from, to)
+	})
+	deferredFKs = map[string]map[string]d.ForeignKey{}
+	order = sortByDependency(dependencyMap)
+	for i := len(order) - 1; i >= 0; i-- {
+		if references, ok := deferred[order[i]]; ok && canModify {
+			var fks map[string]d.ForeignKey
+			dropped[order[i]], fks = withoutForeignKeys(dropped[order[i]], references)
+			down, up := addForeignKeySQL(modifier, order[i], fks)
+			add(foreignKeysName("drop", order[i]), foreignKeysName("drop", order[i]), up, down)
+		}
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		tbl := dropped[order[i]]
+		add(tbl.Name, fmt.Sprintf("drop_%s_table", tbl.Name), sql.DropTableSQL(dialect, tbl), sql.CreateTableSQL(dialect, tbl))
+	}
+	return migrates
+}
+
+// makeDependencyMap makes the map of which tables each table references, limited to the given tables.
+func makeDependencyMap(tables []d.Table) map[string]map[string]struct{} {
+	dependencyMap := map[string]map[string]struct{}{}
+	for _, tbl := range tables {
+		dependencyMap[tbl.Name] = map[string]struct{}{}
+	}
+	for _, tbl := range tables {
+		for _, reference := range tbl.ForeignKeys {
+			name := stringutil.ToSnakeCase(reference.Table)
+			if _, ok := dependencyMap[name]; ok && name != tbl.Name {
+				dependencyMap[tbl.Name][name] = struct{}{}
+			}
+		}
+	}
+	return dependencyMap
+}
+
+// alterTableSQL generates the statements that apply the changes of a table, and the statements that revert them.
+func alterTableSQL(dialect d.Dialect, td *diff.TableDiff) (up []string, down []string) {
+	if rebuilder, ok := dialect.(d.TableRebuilder); ok && needsRebuild(td) {
+		return rebuildTableSQL(rebuilder, td.Old, td.New), rebuildTableSQL(rebuilder, td.New, td.Old)
+	}
+
+	// Foreign keys are dropped before their columns and added after them, or left to be migrated by hand if the dialect cannot.
+	modifier, canModify := dialect.(d.ForeignKeyModifier)
+	var addFKs, dropAddedFKs, readdFKs, dropFKs []string
+	if canModify {
+		addFKs, dropAddedFKs = addForeignKeySQL(modifier, td.New.Name, td.AddedForeignKeys)
+		readdFKs, dropFKs = addForeignKeySQL(modifier, td.New.Name, td.DroppedForeignKeys)
+	} else {
+		addFKs = foreignKeyWarnings(td)
+		readdFKs = foreignKeyWarnings(td)
+	}
+
+	up = append(up, dropFKs...)
+	for _, idx := range td.DroppedIndexes {
+		up = append(up, dialect.DropIndexSQL(idx)...)
+	}
+	for _, f := range td.AddedColumns {
+		up = append(up, dialect.AddColumnSQL(f)...)
+	}
+	for _, c := range td.ModifiedColumns {
+		up = append(up, dialect.ModifyColumnSQL(c.Old, c.New)...)
+	}
+	if td.PrimaryKeyChanged {
+		up = append(up, modifyPrimaryKeySQL(dialect, td.Old, td.New)...)
+	}
+	for _, f := range td.DroppedColumns {
+		up = append(up, dialect.DropColumnSQL(f)...)
+	}
+	for _, idx := range td.AddedIndexes {
+		up = append(up, dialect.CreateIndexSQL(idx)...)
+	}
+	up = append(up, addFKs...)
+
+	down = append(down, dropAddedFKs...)
+	for _, idx := range td.AddedIndexes {
+		down = append(down, dialect.DropIndexSQL(idx)...)
+	}
+	for _, f := range td.DroppedColumns {
+		down = append(down, dialect.AddColumnSQL(f)...)
+	}
+	for _, c := range td.ModifiedColumns {
+		down = append(down, dialect.ModifyColumnSQL(c.New, c.Old)...)
+	}
+	if td.PrimaryKeyChanged {
+		down = append(down, modifyPrimaryKeySQL(dialect, td.New, td.Old)...)
+	}
+	for _, f := range td.AddedColumns {
+		down = append(down, dialect.DropColumnSQL(f)...)
+	}
+	for _, idx := range td.DroppedIndexes {
+		down = append(down, dialect.CreateIndexSQL(idx)...)
+	}
+	down = append(down, readdFKs...)
+	return
+}
+
+// rebuildTableSQL turns the foreign keys off for the rebuild, at the start and the end of the migration where the runner runs them outside the transaction.
+func rebuildTableSQL(rebuilder d.TableRebuilder, oldTable d.Table, newTable d.Table) []string {
+	queries := rebuilder.ForeignKeysSQL(false)
+	queries = append(queries, rebuilder.RebuildTableSQL(oldTable, newTable)...)
+	return append(queries, rebuilder.ForeignKeysSQL(true)...)
+}
+
+// needsRebuild reports whether the changes go beyond what ALTER TABLE can do on dialects that rebuild tables.
+// SQLite refuses to add a NOT NULL column without a default, whatever the rows of the table.
+func needsRebuild(td *diff.TableDiff) bool {
+	for _, f := range td.AddedColumns {
+		if d.NeedsBackfill(f) {
+			return true
+		}
+	}
+	return len(td.DroppedColumns) > 0 ||
+		len(td.ModifiedColumns) > 0 ||
+		td.PrimaryKeyChanged ||
+		len(td.AddedForeignKeys) > 0 ||
+		len(td.DroppedForeignKeys) > 0
+}
+
+func modifyPrimaryKeySQL(dialect d.Dialect, oldTable d.Table, newTable d.Table) []string {
+	modifier, ok := dialect.(d.PrimaryKeyModifier)
+	if !ok {
+		return []string{fmt.Sprintf("-- auto-table: the primary key of %s cannot be modified by this dialect", oldTable.Name)}
+	}
+	return modifier.ModifyPrimaryKeySQL(primaryKeyFields(oldTable), primaryKeyFields(newTable))
+}
+
+func primaryKeyFields(tbl d.Table) []d.Field {
+	fields := make([]d.Field, 0, len(tbl.PrimaryKeys))
+	for _, pk := range tbl.PrimaryKeys {
+		for _, f := range tbl.Fields {
+			if f.Name == pk {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+// foreignKeyWarnings leaves a note in the migration for foreign key changes, which have to be written by hand.
+func foreignKeyWarnings(td *diff.TableDiff) (warnings []string) {
+	var columns []string
+	for column := range td.AddedForeignKeys {
+		columns = append(columns, column)
+	}
+	for column := range td.DroppedForeignKeys {
+		if _, ok := td.AddedForeignKeys[column]; !ok {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		warnings = append(warnings, fmt.Sprintf("-- auto-table: the foreign key of %s.%s changed and has to be migrated by hand", td.New.Name, column))
+	}
+	return
+}
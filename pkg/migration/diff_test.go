@@ -0,0 +1,266 @@
+package migration
+
+import (
+	d "github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/diff"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAlterTableSQL(t *testing.T) {
+	id := d.Field{Table: "post", Name: "id", Type: "BIGINT", AutoIncrement: true}
+	title := d.Field{Table: "post", Name: "title", Type: "VARCHAR(255)"}
+	userID := d.Field{Table: "post", Name: "user_id", Type: "BIGINT"}
+	post := d.Table{Name: "post", Fields: []d.Field{id, title, userID}, PrimaryKeys: []string{"id"}}
+
+	withBody := post
+	withBody.Fields = []d.Field{id, title, userID, {Table: "post", Name: "body", Type: "TEXT", Nullable: true}}
+	withBody.Indexes = []d.Index{{Table: "post", Name: "post_title_idx", Columns: []string{"title"}}}
+
+	shorterTitle := post
+	shorterTitle.Fields = []d.Field{id, {Table: "post", Name: "title", Type: "VARCHAR(80)", Nullable: true}, userID}
+
+	withLikes := post
+	withLikes.Fields = []d.Field{id, title, userID, {Table: "post", Name: "likes", Type: "INTEGER"}}
+
+	intID := post
+	intID.Fields = []d.Field{{Table: "post", Name: "id", Type: "INTEGER", AutoIncrement: true}, title, userID}
+
+	plainID := post
+	plainID.Fields = []d.Field{{Table: "post", Name: "id", Type: "INTEGER"}, title, userID}
+
+	withForeignKey := post
+	withForeignKey.ForeignKeys = map[string]d.ForeignKey{"user_id": {Table: "user", Column: "id"}}
+
+	sqliteRebuild := func(foreignKey string) string {
+		return `PRAGMA foreign_keys = OFF;
+CREATE TABLE IF NOT EXISTS "_post_new" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "title" VARCHAR(255) NOT NULL,
+  "user_id" BIGINT NOT NULL,
+  "created_at" DATETIME DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" DATETIME DEFAULT CURRENT_TIMESTAMP` + foreignKey + `
+);
+INSERT INTO "_post_new" ("created_at", "updated_at", "id", "title", "user_id") SELECT "created_at", "updated_at", "id", "title", "user_id" FROM "post";
+DROP TABLE "post";
+ALTER TABLE "_post_new" RENAME TO "post";
+CREATE TEMP TABLE "_post_foreign_key_check" ("violations" INTEGER CONSTRAINT "foreign keys are violated" CHECK ("violations" = 0));
+INSERT INTO "_post_foreign_key_check" SELECT COUNT(*) FROM pragma_foreign_key_check;
+DROP TABLE "_post_foreign_key_check";
+PRAGMA foreign_keys = ON;`
+	}
+
+	tests := []struct {
+		name    string
+		dialect d.Dialect
+		old     d.Table
+		new     d.Table
+		up      string
+		down    string
+	}{
+		{
+			name:    "mysql add column and index",
+			dialect: d.NewMySQL(),
+			old:     post,
+			new:     withBody,
+			up: "ALTER TABLE `post` ADD `body` TEXT;\n" +
+				"CREATE INDEX `post_title_idx` ON `post` (`title`);",
+			down: "DROP INDEX `post_title_idx` ON `post`;\n" +
+				"ALTER TABLE `post` DROP `body`;",
+		},
+		{
+			name:    "postgres add column and index",
+			dialect: d.NewPostgres(),
+			old:     post,
+			new:     withBody,
+			up: `ALTER TABLE "post" ADD COLUMN "body" TEXT;` + "\n" +
+				`CREATE INDEX "post_title_idx" ON "post" ("title");`,
+			down: `DROP INDEX IF EXISTS "post_title_idx";` + "\n" +
+				`ALTER TABLE "post" DROP COLUMN "body";`,
+		},
+		{
+			name:    "sqlite add column and index",
+			dialect: d.NewSQLite(),
+			old:     post,
+			new:     withBody,
+			up: `ALTER TABLE "post" ADD COLUMN "body" TEXT;` + "\n" +
+				`CREATE INDEX "post_title_idx" ON "post" ("title");`,
+			down: `DROP INDEX IF EXISTS "post_title_idx";` + "\n" +
+				`ALTER TABLE "post" DROP COLUMN "body";`,
+		},
+		{
+			name:    "postgres add NOT NULL column",
+			dialect: d.NewPostgres(),
+			old:     post,
+			new:     withLikes,
+			up: `ALTER TABLE "post" ADD COLUMN "likes" INTEGER NOT NULL DEFAULT 0;` + "\n" +
+				`ALTER TABLE "post" ALTER COLUMN "likes" DROP DEFAULT;`,
+			down: `ALTER TABLE "post" DROP COLUMN "likes";`,
+		},
+		{
+			name:    "sqlite add NOT NULL column rebuilds the table",
+			dialect: d.NewSQLite(),
+			old:     post,
+			new:     withLikes,
+			up: `PRAGMA foreign_keys = OFF;
+CREATE TABLE IF NOT EXISTS "_post_new" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "title" VARCHAR(255) NOT NULL,
+  "user_id" BIGINT NOT NULL,
+  "likes" INTEGER NOT NULL,
+  "created_at" DATETIME DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO "_post_new" ("created_at", "updated_at", "id", "title", "user_id", "likes") SELECT "created_at", "updated_at", "id", "title", "user_id", 0 FROM "post";
+DROP TABLE "post";
+ALTER TABLE "_post_new" RENAME TO "post";
+CREATE TEMP TABLE "_post_foreign_key_check" ("violations" INTEGER CONSTRAINT "foreign keys are violated" CHECK ("violations" = 0));
+INSERT INTO "_post_foreign_key_check" SELECT COUNT(*) FROM pragma_foreign_key_check;
+DROP TABLE "_post_foreign_key_check";
+PRAGMA foreign_keys = ON;`,
+			down: sqliteRebuild(""),
+		},
+		{
+			name:    "mysql modify column",
+			dialect: d.NewMySQL(),
+			old:     post,
+			new:     shorterTitle,
+			up:      "ALTER TABLE `post` CHANGE `title` `title` VARCHAR(80);",
+			down:    "ALTER TABLE `post` CHANGE `title` `title` VARCHAR(255) NOT NULL;",
+		},
+		{
+			name:    "postgres modify column",
+			dialect: d.NewPostgres(),
+			old:     post,
+			new:     shorterTitle,
+			up:      `ALTER TABLE "post" ALTER COLUMN "title" TYPE VARCHAR(80), ALTER COLUMN "title" DROP NOT NULL, ALTER COLUMN "title" DROP DEFAULT;`,
+			down:    `ALTER TABLE "post" ALTER COLUMN "title" TYPE VARCHAR(255), ALTER COLUMN "title" SET NOT NULL, ALTER COLUMN "title" DROP DEFAULT;`,
+		},
+		{
+			name:    "postgres modify serial column",
+			dialect: d.NewPostgres(),
+			old:     post,
+			new:     intID,
+			up: `ALTER TABLE "post" ALTER COLUMN "id" TYPE INTEGER, ALTER COLUMN "id" SET NOT NULL;` + "\n" +
+				`ALTER SEQUENCE "post_id_seq" AS INTEGER;`,
+			down: `ALTER TABLE "post" ALTER COLUMN "id" TYPE BIGINT, ALTER COLUMN "id" SET NOT NULL;` + "\n" +
+				`ALTER SEQUENCE "post_id_seq" AS BIGINT;`,
+		},
+		{
+			name:    "postgres drop serial",
+			dialect: d.NewPostgres(),
+			old:     post,
+			new:     plainID,
+			up: `ALTER TABLE "post" ALTER COLUMN "id" TYPE INTEGER, ALTER COLUMN "id" SET NOT NULL, ALTER COLUMN "id" DROP DEFAULT;` + "\n" +
+				`DROP SEQUENCE IF EXISTS "post_id_seq";`,
+			down: `CREATE SEQUENCE "post_id_seq" AS BIGINT OWNED BY "post"."id";` + "\n" +
+				`ALTER TABLE "post" ALTER COLUMN "id" TYPE BIGINT, ALTER COLUMN "id" SET NOT NULL, ALTER COLUMN "id" SET DEFAULT nextval('"post_id_seq"');` + "\n" +
+				`SELECT setval('"post_id_seq"', COALESCE(MAX("id"), 0) + 1, false) FROM "post";`,
+		},
+		{
+			name:    "mysql add foreign key",
+			dialect: d.NewMySQL(),
+			old:     post,
+			new:     withForeignKey,
+			up:      "ALTER TABLE `post` ADD CONSTRAINT `post_user_id_fkey` FOREIGN KEY (`user_id`) REFERENCES `user`(`id`);",
+			down:    "ALTER TABLE `post` DROP FOREIGN KEY `post_user_id_fkey`;",
+		},
+		{
+			name:    "postgres drop foreign key",
+			dialect: d.NewPostgres(),
+			old:     withForeignKey,
+			new:     post,
+			up:      `ALTER TABLE "post" DROP CONSTRAINT "post_user_id_fkey";`,
+			down:    `ALTER TABLE "post" ADD CONSTRAINT "post_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "user"("id");`,
+		},
+		{
+			name:    "sqlite add foreign key rebuilds the table",
+			dialect: d.NewSQLite(),
+			old:     post,
+			new:     withForeignKey,
+			up:      sqliteRebuild(",\n  FOREIGN KEY (\"user_id\") REFERENCES \"user\"(\"id\")"),
+			down:    sqliteRebuild(""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemaDiff := diff.Compare([]d.Table{tt.old}, []d.Table{tt.new})
+			if len(schemaDiff.ModifiedTables) != 1 {
+				t.Fatalf("Compare() modified %d tables, want 1", len(schemaDiff.ModifiedTables))
+			}
+			up, down := alterTableSQL(tt.dialect, schemaDiff.ModifiedTables[0])
+			if got := strings.Join(up, "\n"); got != tt.up {
+				t.Errorf("up =\n%s\nwant\n%s", got, tt.up)
+			}
+			if got := strings.Join(down, "\n"); got != tt.down {
+				t.Errorf("down =\n%s\nwant\n%s", got, tt.down)
+			}
+		})
+	}
+}
+
+func TestNewDiffMigrateFiles(t *testing.T) {
+	post := d.Table{Name: "post", Fields: []d.Field{{Table: "post", Name: "id", Type: "BIGINT"}}, PrimaryKeys: []string{"id"}}
+	schemaDiff := diff.Compare(nil, []d.Table{post})
+	for _, format := range []Format{golangMigrate{}, annotated{up: "-- +goose Up", down: "-- +goose Down"}} {
+		m := NewDiffMigrate(d.NewMySQL(), schemaDiff, "migrations", Versioning{Sequence: true}, 0, nil)
+		m.Format = format
+		for _, elm := range m.Files(m.Order[0]) {
+			if !strings.HasSuffix(elm.SQL, ";\n") {
+				t.Errorf("%s does not end with a statement and a newline:\n%q", elm.File, elm.SQL)
+			}
+			if strings.Contains(elm.SQL, "\n\n\n") || strings.HasSuffix(elm.SQL, "\n\n") {
+				t.Errorf("%s has blank lines left by the trailing newline:\n%q", elm.File, elm.SQL)
+			}
+		}
+	}
+}
+
+func TestNewDiffMigrateCycles(t *testing.T) {
+	user := d.Table{
+		Name:        "user",
+		Fields:      []d.Field{{Table: "user", Name: "id", Type: "BIGINT"}, {Table: "user", Name: "group_id", Type: "BIGINT"}},
+		PrimaryKeys: []string{"id"},
+		ForeignKeys: map[string]d.ForeignKey{"group_id": {Table: "group", Column: "id"}},
+	}
+	group := d.Table{
+		Name:        "group",
+		Fields:      []d.Field{{Table: "group", Name: "id", Type: "BIGINT"}, {Table: "group", Name: "owner_id", Type: "BIGINT"}},
+		PrimaryKeys: []string{"id"},
+		ForeignKeys: map[string]d.ForeignKey{"owner_id": {Table: "user", Column: "id"}},
+	}
+	positions := map[string]token.Position{
+		"group": {Filename: "models.go", Line: 3, Column: 12},
+		"user":  {Filename: "models.go", Line: 8, Column: 11},
+	}
+	tests := []struct {
+		name    string
+		dialect d.Dialect
+		want    []string
+	}{
+		{
+			name:    "foreign keys added after the tables",
+			dialect: d.NewPostgres(),
+			want:    []string{"models.go:8:11: warning: tables group -> user -> group reference each other, the foreign keys of user referencing group are added after the tables are created"},
+		},
+		{
+			name:    "foreign keys kept inline",
+			dialect: d.NewSQLite(),
+			want:    []string{"models.go:8:11: warning: tables group -> user -> group reference each other, the foreign keys of user referencing group are created before group exists, as the dialect cannot add them to existing tables"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewDiffMigrate(tt.dialect, diff.Compare(nil, []d.Table{user, group}), "migrations", Versioning{Sequence: true}, 0, positions)
+			var got []string
+			for _, diag := range m.Diagnostics {
+				got = append(got, diag.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diagnostics = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
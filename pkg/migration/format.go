@@ -0,0 +1,122 @@
+package migration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultFormat is the format of the migrations ReadDir and the runner understand.
+const DefaultFormat = "golang-migrate"
+
+// Format lays out a migration in the files of a migration tool.
+type Format interface {
+	Files(m *Migrate) []*MigrateElm
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]Format{}
+)
+
+func init() {
+	RegisterFormat(DefaultFormat, golangMigrate{})
+	RegisterFormat("goose", annotated{up: "-- +goose Up", down: "-- +goose Down"})
+	RegisterFormat("sql-migrate", annotated{up: "-- +migrate Up", down: "-- +migrate Down"})
+	RegisterFormat("dbmate", annotated{up: "-- migrate:up", down: "-- migrate:down"})
+	RegisterFormat("flyway", flyway{})
+	RegisterFormat("liquibase", liquibase{})
+}
+
+// RegisterFormat makes a format available by the provided name.
+// If RegisterFormat is called twice with the same name or if format is nil, it panics.
+func RegisterFormat(name string, format Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	if format == nil {
+		panic("auto-table: RegisterFormat format is nil")
+	}
+	name = strings.ToLower(name)
+	if _, dup := formats[name]; dup {
+		panic("auto-table: RegisterFormat called twice for format " + name)
+	}
+	formats[name] = format
+}
+
+// NewFormat returns the format registered by the provided name.
+func NewFormat(name string) (Format, error) {
+	formatsMu.RLock()
+	format, ok := formats[strings.ToLower(name)]
+	formatsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("auto-table: unknown migration format %q (registered: %s)", name, strings.Join(FormatNames(), ", "))
+	}
+	return format, nil
+}
+
+// FormatNames returns a sorted list of the names of the registered formats.
+func FormatNames() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// golangMigrate writes the up and down statements to <version>_<name>.up.sql and <version>_<name>.down.sql.
+type golangMigrate struct{}
+
+func (golangMigrate) Files(m *Migrate) []*MigrateElm {
+	files := []*MigrateElm{{File: fmt.Sprintf("%s_%s%s", m.version(), m.Name, upSuffix), SQL: m.Up.SQL}}
+	if m.Down != nil {
+		files = append(files, &MigrateElm{File: fmt.Sprintf("%s_%s%s", m.version(), m.Name, downSuffix), SQL: m.Down.SQL})
+	}
+	return files
+}
+
+// annotated writes a single <version>_<name>.sql file, whose up and down sections start with annotation comments.
+// goose, sql-migrate and dbmate differ only in the annotations.
+type annotated struct {
+	up   string
+	down string
+}
+
+func (a annotated) Files(m *Migrate) []*MigrateElm {
+	content := fmt.Sprintf("%s\n%s\n", a.up, strings.TrimSuffix(m.Up.SQL, "\n"))
+	if m.Down != nil {
+		content += fmt.Sprintf("\n%s\n%s\n", a.down, strings.TrimSuffix(m.Down.SQL, "\n"))
+	}
+	return []*MigrateElm{{File: fmt.Sprintf("%s_%s.sql", m.version(), m.Name), SQL: content}}
+}
+
+// flyway writes a versioned migration V<version>__<name>.sql and the undo migration U<version>__<name>.sql.
+type flyway struct{}
+
+func (flyway) Files(m *Migrate) []*MigrateElm {
+	files := []*MigrateElm{{File: fmt.Sprintf("V%s__%s.sql", m.version(), m.Name), SQL: m.Up.SQL}}
+	if m.Down != nil {
+		files = append(files, &MigrateElm{File: fmt.Sprintf("U%s__%s.sql", m.version(), m.Name), SQL: m.Down.SQL})
+	}
+	return files
+}
+
+// liquibase writes a formatted SQL changelog of a single changeset, with the down statements as its rollback.
+// The changelogs are meant to be included in order by includeAll.
+type liquibase struct{}
+
+func (liquibase) Files(m *Migrate) []*MigrateElm {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--liquibase formatted sql\n\n--changeset auto-table:%s_%s\n%s\n", m.version(), m.Name, strings.TrimSuffix(m.Up.SQL, "\n"))
+	if m.Down != nil {
+		for _, line := range strings.Split(m.Down.SQL, "\n") {
+			if strings.TrimSpace(line) != "" {
+				fmt.Fprintf(&b, "--rollback %s\n", line)
+			}
+		}
+	}
+	return []*MigrateElm{{File: fmt.Sprintf("%s_%s.sql", m.version(), m.Name), SQL: b.String()}}
+}
@@ -0,0 +1,82 @@
+package migration
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	m := &Migrate{
+		Version: 1,
+		Digits:  6,
+		Name:    "add_user_table",
+		Up:      &MigrateElm{SQL: "CREATE TABLE `user` (\n  `id` BIGINT\n);\n"},
+		Down:    &MigrateElm{SQL: "DROP TABLE `user`;\n"},
+	}
+	tests := []struct {
+		format string
+		want   []MigrateElm
+	}{
+		{
+			format: DefaultFormat,
+			want: []MigrateElm{
+				{File: "000001_add_user_table.up.sql", SQL: m.Up.SQL},
+				{File: "000001_add_user_table.down.sql", SQL: m.Down.SQL},
+			},
+		},
+		{
+			format: "goose",
+			want: []MigrateElm{{File: "000001_add_user_table.sql", SQL: "-- +goose Up\n" +
+				"CREATE TABLE `user` (\n  `id` BIGINT\n);\n\n" +
+				"-- +goose Down\n" +
+				"DROP TABLE `user`;\n"}},
+		},
+		{
+			format: "sql-migrate",
+			want: []MigrateElm{{File: "000001_add_user_table.sql", SQL: "-- +migrate Up\n" +
+				"CREATE TABLE `user` (\n  `id` BIGINT\n);\n\n" +
+				"-- +migrate Down\n" +
+				"DROP TABLE `user`;\n"}},
+		},
+		{
+			format: "dbmate",
+			want: []MigrateElm{{File: "000001_add_user_table.sql", SQL: "-- migrate:up\n" +
+				"CREATE TABLE `user` (\n  `id` BIGINT\n);\n\n" +
+				"-- migrate:down\n" +
+				"DROP TABLE `user`;\n"}},
+		},
+		{
+			format: "Flyway",
+			want: []MigrateElm{
+				{File: "V000001__add_user_table.sql", SQL: m.Up.SQL},
+				{File: "U000001__add_user_table.sql", SQL: m.Down.SQL},
+			},
+		},
+		{
+			format: "liquibase",
+			want: []MigrateElm{{File: "000001_add_user_table.sql", SQL: "--liquibase formatted sql\n\n" +
+				"--changeset auto-table:000001_add_user_table\n" +
+				"CREATE TABLE `user` (\n  `id` BIGINT\n);\n" +
+				"--rollback DROP TABLE `user`;\n"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			format, err := NewFormat(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []MigrateElm
+			for _, elm := range format.Files(m) {
+				got = append(got, *elm)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Files() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewFormat("alembic"); err == nil {
+		t.Error("NewFormat() of an unknown format succeeded")
+	}
+}
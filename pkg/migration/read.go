@@ -0,0 +1,129 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/spf13/afero"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	upSuffix   = ".up.sql"
+	downSuffix = ".down.sql"
+)
+
+// ReadDir reads the migration files in the directory. The migrations are keyed by their file name without the suffix and ordered by version.
+// Only the files of golang-migrate are understood, other SQL files are an error.
+func ReadDir(fs *afero.Fs, dir string) (*Migrates, error) {
+	infos, err := afero.ReadDir(*fs, dir)
+	if err != nil {
+		return nil, err
+	}
+	migrates := newMigrates(dir)
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		filename := info.Name()
+		var suffix string
+		switch {
+		case strings.HasSuffix(filename, upSuffix):
+			suffix = upSuffix
+		case strings.HasSuffix(filename, downSuffix):
+			suffix = downSuffix
+		case strings.HasSuffix(filename, ".sql"):
+			return nil, fmt.Errorf("auto-table: %s is not a %s migration, which are the only migrations that can be run", filename, DefaultFormat)
+		default:
+			continue
+		}
+		key := strings.TrimSuffix(filename, suffix)
+		version, name, ok := parseFilename(key)
+		if !ok {
+			continue
+		}
+		b, err := afero.ReadFile(*fs, path.Join(dir, filename))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := migrates.Map[key]
+		if !ok {
+			m = &Migrate{Version: version, Name: name}
+			migrates.Map[key] = m
+			migrates.Order = append(migrates.Order, key)
+		}
+		elm := &MigrateElm{File: filename, SQL: string(b)}
+		if suffix == upSuffix {
+			m.Up = elm
+		} else {
+			m.Down = elm
+		}
+	}
+
+	versions := map[int64]string{}
+	for _, key := range migrates.Order {
+		m := migrates.Map[key]
+		if m.Up == nil {
+			return nil, fmt.Errorf("auto-table: %s%s is missing", key, upSuffix)
+		}
+		if other, ok := versions[m.Version]; ok {
+			return nil, fmt.Errorf("auto-table: %s and %s have the same version", other, key)
+		}
+		versions[m.Version] = key
+	}
+	sort.Slice(migrates.Order, func(i, j int) bool {
+		return migrates.Map[migrates.Order[i]].Version < migrates.Map[migrates.Order[j]].Version
+	})
+	return migrates, nil
+}
+
+// LatestVersion returns the largest version of the migration files in the directory, or 0 if there are none.
+func LatestVersion(fs *afero.Fs, dir string) (latest int64, err error) {
+	infos, err := afero.ReadDir(*fs, dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		name = strings.TrimSuffix(name, ".sql")
+		if len(name) > 1 && (name[0] == 'V' || name[0] == 'U') && name[1] >= '0' && name[1] <= '9' {
+			// Versioned and undo migrations of Flyway
+			name = name[1:]
+		}
+		version, _, ok := parseFilename(name)
+		if ok && version > latest {
+			latest = version
+		}
+	}
+	return
+}
+
+// Checksum returns a checksum of the statements of a migration file, used to notice files edited after they were applied.
+func Checksum(sql string) string {
+	sum := sha256.Sum256([]byte(sql))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseFilename splits a file name like 1617000000_add_user_table into its version and name.
+func parseFilename(s string) (version int64, name string, ok bool) {
+	i := strings.IndexByte(s, '_')
+	if i < 1 {
+		return 0, "", false
+	}
+	version, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return version, s[i+1:], true
+}
@@ -0,0 +1,65 @@
+package migration
+
+import "strings"
+
+// SplitStatements splits the contents of a migration file into statements without their terminating semicolons.
+// Semicolons inside quotes and comments do not end a statement, and comments are removed.
+func SplitStatements(sql string) (statements []string) {
+	var b strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(b.String()); s != "" {
+			statements = append(statements, s)
+		}
+		b.Reset()
+	}
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// Quoted string or identifier, a doubled quote is an escaped quote.
+			j := i + 1
+			for ; j < len(sql); j++ {
+				if sql[j] == '\\' && c == '\'' {
+					j++
+					continue
+				}
+				if sql[j] == c {
+					if j+1 < len(sql) && sql[j+1] == c {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= len(sql) {
+				j = len(sql) - 1
+			}
+			b.WriteString(sql[i : j+1])
+			i = j
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			// Line comment
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				i = len(sql)
+				continue
+			}
+			i += j
+			b.WriteByte('\n')
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			// Block comment
+			j := strings.Index(sql[i+2:], "*/")
+			if j < 0 {
+				i = len(sql)
+				continue
+			}
+			i += j + 3
+			b.WriteByte(' ')
+		case c == ';':
+			flush()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+	return
+}
@@ -0,0 +1,57 @@
+package migration
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "statements",
+			sql:  "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			want: []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name: "last statement without semicolon",
+			sql:  "DROP TABLE a;\nDROP TABLE b",
+			want: []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+		{
+			name: "semicolons in quotes",
+			sql:  "INSERT INTO a VALUES ('x;y', \"c;d\", `e;f`);",
+			want: []string{"INSERT INTO a VALUES ('x;y', \"c;d\", `e;f`)"},
+		},
+		{
+			name: "escaped quotes",
+			sql:  "INSERT INTO a VALUES ('it''s;', 'a\\';b');SELECT 1;",
+			want: []string{"INSERT INTO a VALUES ('it''s;', 'a\\';b')", "SELECT 1"},
+		},
+		{
+			name: "comments removed",
+			sql:  "-- auto-table: note; not a statement\nSELECT 1; /* a; b */ SELECT 2;",
+			want: []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name: "only comments",
+			sql:  "-- nothing to do\n",
+			want: nil,
+		},
+		{
+			name: "unterminated quote",
+			sql:  "SELECT 'a;",
+			want: []string{"SELECT 'a;"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitStatements(tt.sql); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package query
+
+import (
+	"fmt"
+	sql "github.com/hourglasshoro/auto-table/pkg/sql"
+	"github.com/hourglasshoro/auto-table/pkg/utils"
+	"github.com/naoina/go-stringutil"
+	"github.com/spf13/afero"
+	"sort"
+	"strings"
+)
+
+// Commands of sqlc telling what a query returns.
+const (
+	CommandOne  = ":one"
+	CommandMany = ":many"
+	CommandExec = ":exec"
+)
+
+type Query struct {
+	Name    string // Like GetUser
+	Command string
+	SQL     string
+}
+
+type File struct {
+	File    string
+	Queries []*Query
+}
+
+type Queries struct {
+	Map       map[string]*File // map[tableName]File
+	Order     []string         // []tableName
+	OutputDir string
+}
+
+// NewQueries makes a named query file of the CRUD statements of each table, in the format of sqlc.
+func NewQueries(sqls map[string]*sql.SQL, output string) *Queries {
+	queries := &Queries{
+		Map:       map[string]*File{},
+		OutputDir: output,
+	}
+	for tableName := range sqls {
+		queries.Order = append(queries.Order, tableName)
+	}
+	sort.Strings(queries.Order)
+
+	for _, tableName := range queries.Order {
+		s := sqls[tableName]
+		name := s.Source.Struct
+		if name == "" {
+			name = stringutil.ToUpperCamelCase(tableName)
+		}
+		file := &File{
+			File: fmt.Sprintf("%s.sql", tableName),
+			Queries: []*Query{
+				{Name: "List" + utils.Plural(name), Command: CommandMany, SQL: s.Record.FindAll},
+				{Name: "Get" + name, Command: CommandOne, SQL: s.Record.Find},
+				{Name: "Create" + name, Command: CommandExec, SQL: s.Record.Create},
+				{Name: "Update" + name, Command: CommandExec, SQL: s.Record.Update},
+				{Name: "Delete" + name, Command: CommandExec, SQL: s.Record.Delete},
+			},
+		}
+		if s.Record.Upsert != "" {
+			file.Queries = append(file.Queries,
+				&Query{Name: "Upsert" + name, Command: CommandExec, SQL: s.Record.Upsert},
+				&Query{Name: "BatchCreate" + utils.Plural(name), Command: CommandExec, SQL: s.Record.BatchCreate},
+				&Query{Name: "List" + utils.Plural(name) + "After", Command: CommandMany, SQL: s.Record.Paginate},
+			)
+		}
+		for _, index := range s.Schema.Indexes {
+			if statement, ok := s.Record.FindBy[index.Name]; ok {
+				by := make([]string, len(index.Columns))
+				for i, c := range index.Columns {
+					by[i] = stringutil.ToUpperCamelCase(c)
+				}
+				file.Queries = append(file.Queries, &Query{Name: "Find" + name + "By" + strings.Join(by, "And"), Command: CommandOne, SQL: statement})
+			}
+		}
+		for _, relation := range s.Relations {
+			if relation.Kind == sql.RelationParent {
+				file.Queries = append(file.Queries, &Query{Name: "Get" + name + relation.Name, Command: CommandOne, SQL: relation.SQL})
+			} else {
+				file.Queries = append(file.Queries, &Query{Name: "List" + name + relation.Name, Command: CommandMany, SQL: relation.SQL})
+			}
+		}
+		queries.Map[tableName] = file
+	}
+	return queries
+}
+
+// String returns the content of the file, each query preceded by its -- name: header.
+func (f *File) String() string {
+	blocks := make([]string, len(f.Queries))
+	for i, q := range f.Queries {
+		blocks[i] = fmt.Sprintf("-- name: %s %s\n%s\n", q.Name, q.Command, q.SQL)
+	}
+	return strings.Join(blocks, "\n")
+}
+
+func (q *Queries) WriteFile(fs *afero.Fs) (err error) {
+	err = (*fs).MkdirAll(q.OutputDir, 0755)
+	if err != nil {
+		return
+	}
+	for _, tableName := range q.Order {
+		output := fmt.Sprintf("%s/%s", q.OutputDir, q.Map[tableName].File)
+		err = afero.WriteFile(*fs, output, []byte(q.Map[tableName].String()), 0644)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package query
+
+import (
+	sql "github.com/hourglasshoro/auto-table/pkg/sql"
+	"github.com/spf13/afero"
+	"strings"
+	"testing"
+)
+
+func TestNewQueries(t *testing.T) {
+	sqls := map[string]*sql.SQL{
+		"user_category": {
+			Source: sql.Source{Struct: "Category"},
+			Record: sql.Record{
+				FindAll: "SELECT `id` FROM `user_category`;",
+				Find:    "SELECT `id` FROM `user_category` WHERE `id` = ?;",
+				Create:  "INSERT INTO `user_category` () VALUES ();",
+				Update:  "UPDATE `user_category` SET `updated_at` = CURRENT_TIMESTAMP WHERE `id` = ?;",
+				Delete:  "DELETE FROM `user_category` WHERE `id` = ?;",
+			},
+		},
+		"box": {
+			Record: sql.Record{FindAll: "SELECT `id` FROM `box`;"},
+		},
+	}
+	queries := NewQueries(sqls, "queries")
+
+	if got, want := strings.Join(queries.Order, ","), "box,user_category"; got != want {
+		t.Errorf("Order = %s, want %s", got, want)
+	}
+	var names []string
+	for _, q := range queries.Map["box"].Queries {
+		names = append(names, q.Name)
+	}
+	if got, want := strings.Join(names, ","), "ListBoxes,GetBox,CreateBox,UpdateBox,DeleteBox"; got != want {
+		t.Errorf("queries of box = %s, want %s", got, want)
+	}
+
+	fs := afero.NewMemMapFs()
+	if err := queries.WriteFile(&fs); err != nil {
+		t.Fatal(err)
+	}
+	got, err := afero.ReadFile(fs, "queries/user_category.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "-- name: ListCategories :many\n" +
+		"SELECT `id` FROM `user_category`;\n\n" +
+		"-- name: GetCategory :one\n" +
+		"SELECT `id` FROM `user_category` WHERE `id` = ?;\n\n" +
+		"-- name: CreateCategory :exec\n" +
+		"INSERT INTO `user_category` () VALUES ();\n\n" +
+		"-- name: UpdateCategory :exec\n" +
+		"UPDATE `user_category` SET `updated_at` = CURRENT_TIMESTAMP WHERE `id` = ?;\n\n" +
+		"-- name: DeleteCategory :exec\n" +
+		"DELETE FROM `user_category` WHERE `id` = ?;\n"
+	if string(got) != want {
+		t.Errorf("user_category.sql =\n%s\nwant\n%s", got, want)
+	}
+}
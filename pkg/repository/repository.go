@@ -0,0 +1,574 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	d "github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/sql"
+	"github.com/hourglasshoro/auto-table/pkg/utils"
+	"github.com/spf13/afero"
+	"go/format"
+	goparser "go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DBFile is the file declaring what the repositories share.
+const DBFile = "db.go"
+
+const header = "// Code generated by auto-table. DO NOT EDIT.\n\n"
+
+const dbSource = `
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX is what the repositories run their statements on, like *sql.DB, *sql.Conn or *sql.Tx.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// scanner is a *sql.Row or *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+`
+
+type Repositories struct {
+	Map       map[string][]byte // map[filename]source
+	Order     []string          // []filename
+	OutputDir string
+}
+
+// column is a column of a table read from and written to a field of the struct.
+type column struct {
+	field  string // Name of the struct field
+	goType string
+	d.Field
+}
+
+// model is a table made from a struct, with the columns the repository reads and writes.
+type model struct {
+	sql         *sql.SQL
+	importPath  string
+	table       d.Table // The table of the columns only
+	columns     []column
+	keys        []column
+	fileImports map[string]string // map[name]importPath, of the file the struct is declared in
+}
+
+// NewRepositories generates a repository over database/sql for each table made from a struct.
+// The repository reads and writes the struct itself, so it is imported by the path importPaths gives for its source file.
+// Columns that do not map to a struct field of the same name and type, like the IDs of related structs, are left out.
+func NewRepositories(dialect d.Dialect, sqls map[string]*sql.SQL, output string, importPaths map[string]string) (*Repositories, error) {
+	packageName := PackageName(output)
+	repositories := &Repositories{
+		Map:       map[string][]byte{},
+		OutputDir: output,
+	}
+	tableNames := make([]string, 0, len(sqls))
+	for tableName := range sqls {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+
+	models := map[string]*model{} // map[tableName]model
+	for _, tableName := range tableNames {
+		s := sqls[tableName]
+		if s.Source.Struct == "" {
+			// Cross reference tables have no struct to scan into.
+			continue
+		}
+		importPath, ok := importPaths[s.Source.Filename]
+		if !ok {
+			return nil, fmt.Errorf("auto-table: import path of %s is unknown", s.Source.Filename)
+		}
+		m, err := newModel(dialect, s, importPath)
+		if err != nil {
+			return nil, fmt.Errorf("auto-table: %s: %v", tableName, err)
+		}
+		if m != nil {
+			models[tableName] = m
+		}
+	}
+
+	for _, tableName := range tableNames {
+		m, ok := models[tableName]
+		if !ok {
+			continue
+		}
+		src, err := generateTable(dialect, m, models, sqls, packageName)
+		if err != nil {
+			return nil, fmt.Errorf("auto-table: %s: %v", tableName, err)
+		}
+		filename := tableName + "_repository.go"
+		repositories.Map[filename] = src
+		repositories.Order = append(repositories.Order, filename)
+	}
+	if len(repositories.Order) > 0 {
+		src, err := format.Source([]byte(header + "package " + packageName + "\n" + dbSource))
+		if err != nil {
+			return nil, err
+		}
+		repositories.Map[DBFile] = src
+		repositories.Order = append([]string{DBFile}, repositories.Order...)
+	}
+	return repositories, nil
+}
+
+func (r *Repositories) WriteFile(fs *afero.Fs) (err error) {
+	err = (*fs).MkdirAll(r.OutputDir, 0755)
+	if err != nil {
+		return
+	}
+	for _, filename := range r.Order {
+		err = afero.WriteFile(*fs, fmt.Sprintf("%s/%s", r.OutputDir, filename), r.Map[filename], 0644)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// PackageName returns the name of the package generated into the directory.
+func PackageName(dir string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, path.Base(strings.ReplaceAll(dir, "\\", "/")))
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) || token.IsKeyword(name) {
+		return "repository"
+	}
+	return name
+}
+
+// newModel returns the model of a table, or nil if the records of the table cannot be told apart by struct fields.
+func newModel(dialect d.Dialect, s *sql.SQL, importPath string) (*model, error) {
+	m := &model{
+		sql:        s,
+		importPath: importPath,
+		table: d.Table{
+			Name:   s.Schema.Name,
+			Option: s.Schema.Option,
+		},
+	}
+	for i, f := range s.Schema.Fields {
+		if !s.Fields[i].IsStructField() || s.Fields[i].Name == "_" {
+			continue
+		}
+		m.table.Fields = append(m.table.Fields, f)
+		m.columns = append(m.columns, column{field: s.Fields[i].Name, goType: s.Fields[i].GoType, Field: f})
+	}
+	if len(m.columns) == 0 {
+		log.Printf("auto-table: no repository for %s: no column is read into a field of %s", s.Schema.Name, s.Source.Struct)
+		return nil, nil
+	}
+	for _, pk := range s.Schema.PrimaryKeys {
+		if findColumn(m.columns, pk) == nil {
+			log.Printf("auto-table: no repository for %s: the primary key %s is not a field of %s", s.Schema.Name, pk, s.Source.Struct)
+			return nil, nil
+		}
+	}
+	m.table.PrimaryKeys = s.Schema.PrimaryKeys
+	m.keys = keyColumns(m.table, m.columns)
+
+	fileImports, err := parseImports(s.Source.Filename)
+	if err != nil {
+		return nil, err
+	}
+	m.fileImports = fileImports
+	return m, nil
+}
+
+// writer collects the statements, declarations and imports of a repository file.
+type writer struct {
+	consts  []string // name = statement
+	body    bytes.Buffer
+	imports map[string]string // map[importPath]name
+}
+
+func (w *writer) constant(name string, statements []string) {
+	w.consts = append(w.consts, fmt.Sprintf("%s = %s", name, quote(strings.TrimSuffix(strings.Join(statements, "\n"), ";"))))
+}
+
+// structName returns the name of the struct of the model in the repository package, adding its import.
+func (w *writer) structName(m *model) string {
+	if path.Base(m.importPath) == m.sql.Source.Package {
+		w.imports[m.importPath] = ""
+	} else {
+		w.imports[m.importPath] = m.sql.Source.Package
+	}
+	return m.sql.Source.Package + "." + m.sql.Source.Struct
+}
+
+// params returns the parameters taking the values of the columns and the arguments passing them on.
+func (w *writer) params(m *model, columns []column) (params []string, args []string, err error) {
+	for _, c := range columns {
+		name := paramName(c.field)
+		goType, qErr := qualify(c.goType, m.sql.Source.Package, m.fileImports, w.imports)
+		if qErr != nil {
+			return nil, nil, qErr
+		}
+		params = append(params, fmt.Sprintf("%s %s", name, goType))
+		args = append(args, name)
+	}
+	return
+}
+
+func (w *writer) source(packageName string) ([]byte, error) {
+	var src bytes.Buffer
+	src.WriteString(header)
+	fmt.Fprintf(&src, "package %s\n\n", packageName)
+	importList := make([]string, 0, len(w.imports))
+	for p, name := range w.imports {
+		importList = append(importList, strings.TrimSpace(name+" "+strconv.Quote(p)))
+	}
+	// format.Source sorts them by path.
+	fmt.Fprintf(&src, "import (\n%s\n)\n\n", strings.Join(importList, "\n"))
+	fmt.Fprintf(&src, "const (\n%s\n)\n\n", strings.Join(w.consts, "\n"))
+	src.Write(w.body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+// generateTable writes the repository of a model.
+// The repository runs the statements of the columns it knows, so they are made again for the tables of those columns.
+func generateTable(dialect d.Dialect, m *model, models map[string]*model, sqls map[string]*sql.SQL, packageName string) ([]byte, error) {
+	w := &writer{imports: map[string]string{"context": "", "database/sql": ""}}
+	structName := m.sql.Source.Struct
+	model := w.structName(m)
+	repository := structName + "Repository"
+	params, args, err := w.params(m, m.keys)
+	if err != nil {
+		return nil, err
+	}
+	var keyArgs []string
+	for _, k := range m.keys {
+		keyArgs = append(keyArgs, "m."+k.field)
+	}
+
+	isKey := map[string]struct{}{}
+	for _, k := range m.keys {
+		isKey[k.Name] = struct{}{}
+	}
+	var inserted, updated []string
+	var autoIncrement *column
+	for i, c := range m.columns {
+		if c.AutoIncrement {
+			if autoIncrement == nil {
+				autoIncrement = &m.columns[i]
+			}
+			continue
+		}
+		inserted = append(inserted, "m."+c.field)
+		if _, ok := isKey[c.Name]; !ok {
+			updated = append(updated, "m."+c.field)
+		}
+	}
+
+	consts := []string{"findAll", "find", "create", "update", "delete"}
+	for i, c := range consts {
+		consts[i] = c + structName + "SQL"
+	}
+	w.constant(consts[0], dialect.FindAllSQL(m.table))
+	w.constant(consts[1], dialect.FindSQL(m.table))
+	w.constant(consts[2], dialect.CreateSQL(m.table))
+	w.constant(consts[3], dialect.UpdateSQL(m.table))
+	w.constant(consts[4], dialect.DeleteSQL(m.table))
+
+	src := &w.body
+	fmt.Fprintf(src, "// %s reads and writes %s records in the %s table.\n", repository, structName, m.table.Name)
+	fmt.Fprintf(src, "type %s struct {\n\tdb DBTX\n}\n\n", repository)
+	fmt.Fprintf(src, "func New%s(db DBTX) *%s {\n\treturn &%s{db: db}\n}\n\n", repository, repository, repository)
+
+	fmt.Fprintf(src, "// FindAll returns every %s.\n", structName)
+	fmt.Fprintf(src, "func (r *%s) FindAll(ctx context.Context) ([]*%s, error) {\n\treturn scan%s(r.db.QueryContext(ctx, %s))\n}\n\n",
+		repository, model, utils.Plural(structName), consts[0])
+
+	fmt.Fprintf(src, "// Find returns the %s of the key, or sql.ErrNoRows if there is none.\n", structName)
+	fmt.Fprintf(src, "func (r *%s) Find(ctx context.Context, %s) (*%s, error) {\n\treturn scan%s(r.db.QueryRowContext(ctx, %s))\n}\n\n",
+		repository, strings.Join(params, ", "), model, structName, strings.Join(append([]string{consts[1]}, args...), ", "))
+
+	create := strings.Join(append([]string{consts[2]}, inserted...), ", ")
+	if autoIncrement != nil {
+		idType, qErr := qualify(strings.TrimPrefix(autoIncrement.goType, "*"), m.sql.Source.Package, m.fileImports, w.imports)
+		if qErr != nil {
+			return nil, qErr
+		}
+		fmt.Fprintf(src, "// Create inserts the %s. %s is set to the value the database assigns when the driver reports it.\n", structName, autoIncrement.field)
+		fmt.Fprintf(src, `func (r *%s) Create(ctx context.Context, m *%s) error {
+	res, err := r.db.ExecContext(ctx, %s)
+	if err != nil {
+		return err
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		m.%s = %s(id)
+	}
+	return nil
+}
+
+`, repository, model, create, autoIncrement.field, idType)
+	} else {
+		fmt.Fprintf(src, "// Create inserts the %s.\n", structName)
+		fmt.Fprintf(src, "func (r *%s) Create(ctx context.Context, m *%s) error {\n\t_, err := r.db.ExecContext(ctx, %s)\n\treturn err\n}\n\n", repository, model, create)
+	}
+
+	fmt.Fprintf(src, "// Update writes the fields of the %s to the record of its key.\n", structName)
+	fmt.Fprintf(src, "func (r *%s) Update(ctx context.Context, m *%s) error {\n\t_, err := r.db.ExecContext(ctx, %s)\n\treturn err\n}\n\n",
+		repository, model, strings.Join(append(append([]string{consts[3]}, updated...), keyArgs...), ", "))
+
+	fmt.Fprintf(src, "// Delete deletes the %s of the key.\n", structName)
+	fmt.Fprintf(src, "func (r *%s) Delete(ctx context.Context, %s) error {\n\t_, err := r.db.ExecContext(ctx, %s)\n\treturn err\n}\n\n",
+		repository, strings.Join(params, ", "), strings.Join(append([]string{consts[4]}, args...), ", "))
+
+	if querier, ok := dialect.(d.RelationQuerier); ok {
+		for _, relation := range m.sql.Relations {
+			if err := w.relation(querier, m, relation, models, sqls); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var dest []string
+	for _, c := range m.columns {
+		dest = append(dest, "&m."+c.field)
+	}
+	// Every table has the created_at and updated_at columns, which the struct may not declare.
+	fmt.Fprintf(src, `func scan%s(row scanner) (*%s, error) {
+	var m %s
+	var createdAt, updatedAt interface{}
+	if err := row.Scan(%s, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+`, structName, model, model, strings.Join(dest, ", "))
+	records := lowerFirst(utils.Plural(structName))
+	fmt.Fprintf(src, `func scan%s(rows *sql.Rows, err error) ([]*%s, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var %s []*%s
+	for rows.Next() {
+		m, err := scan%s(rows)
+		if err != nil {
+			return nil, err
+		}
+		%s = append(%s, m)
+	}
+	return %s, rows.Err()
+}
+`, utils.Plural(structName), model, records, model, structName, records, records, records)
+
+	return w.source(packageName)
+}
+
+// relation writes the method reading the records of a relation, and the method loading them into the struct field if there is one.
+// Relations to tables without a repository are left out.
+func (w *writer) relation(querier d.RelationQuerier, m *model, relation *sql.Relation, models map[string]*model, sqls map[string]*sql.SQL) error {
+	target, ok := models[relation.Table]
+	if !ok {
+		return nil
+	}
+	structName := m.sql.Source.Struct
+	repository := structName + "Repository"
+	model := w.structName(m)
+	targetModel := w.structName(target)
+	targetName := target.sql.Source.Struct
+	constName := "find" + structName + relation.Name + "SQL"
+
+	if relation.Kind == sql.RelationParent {
+		params, args, err := w.params(m, m.keys)
+		if err != nil {
+			return err
+		}
+		var keyArgs []string
+		for _, k := range m.keys {
+			keyArgs = append(keyArgs, "m."+k.field)
+		}
+		w.constant(constName, querier.ParentSQL(m.table, relation.Column, target.table, relation.References))
+		fmt.Fprintf(&w.body, "// Find%s returns the %s that %s of the %s of the key references.\n", relation.Name, targetName, relation.Column, structName)
+		fmt.Fprintf(&w.body, "func (r *%s) Find%s(ctx context.Context, %s) (*%s, error) {\n\treturn scan%s(r.db.QueryRowContext(ctx, %s))\n}\n\n",
+			repository, relation.Name, strings.Join(params, ", "), targetModel, targetName, strings.Join(append([]string{constName}, args...), ", "))
+		if relation.Field == "" {
+			return nil
+		}
+		value := "*record"
+		if strings.HasPrefix(relation.FieldType, "*") {
+			value = "record"
+		}
+		fmt.Fprintf(&w.body, "// Load%s sets %s of the %s to the %s it references.\n", relation.Field, relation.Field, structName, targetName)
+		fmt.Fprintf(&w.body, `func (r *%s) Load%s(ctx context.Context, m *%s) error {
+	record, err := r.Find%s(ctx, %s)
+	if err != nil {
+		return err
+	}
+	m.%s = %s
+	return nil
+}
+
+`, repository, relation.Field, model, relation.Name, strings.Join(keyArgs, ", "), relation.Field, value)
+		return nil
+	}
+
+	key := findColumn(m.columns, relation.Key)
+	if key == nil {
+		return nil
+	}
+	params, args, err := w.params(m, []column{*key})
+	if err != nil {
+		return err
+	}
+	if relation.Kind == sql.RelationMany {
+		w.constant(constName, querier.ThroughSQL(target.table, relation.References, sqls[relation.Through].Schema, relation.From, relation.Column))
+		fmt.Fprintf(&w.body, "// FindAll%s returns the %s linked to the %s of %s by %s.\n", relation.Name, utils.Plural(targetName), structName, key.Name, relation.Through)
+	} else {
+		w.constant(constName, querier.ChildrenSQL(target.table, relation.Column))
+		fmt.Fprintf(&w.body, "// FindAll%s returns the %s whose %s references the %s of %s.\n", relation.Name, utils.Plural(targetName), relation.Column, structName, key.Name)
+	}
+	fmt.Fprintf(&w.body, "func (r *%s) FindAll%s(ctx context.Context, %s) ([]*%s, error) {\n\treturn scan%s(r.db.QueryContext(ctx, %s))\n}\n\n",
+		repository, relation.Name, params[0], targetModel, utils.Plural(targetName), strings.Join(append([]string{constName}, args...), ", "))
+	if relation.Field == "" {
+		return nil
+	}
+	fmt.Fprintf(&w.body, "// Load%s sets %s of the %s to the %s linked to it.\n", relation.Field, relation.Field, structName, utils.Plural(targetName))
+	if strings.HasPrefix(relation.FieldType, "[]*") {
+		fmt.Fprintf(&w.body, `func (r *%s) Load%s(ctx context.Context, m *%s) error {
+	records, err := r.FindAll%s(ctx, m.%s)
+	if err != nil {
+		return err
+	}
+	m.%s = records
+	return nil
+}
+
+`, repository, relation.Field, model, relation.Name, key.field, relation.Field)
+		return nil
+	}
+	fmt.Fprintf(&w.body, `func (r *%s) Load%s(ctx context.Context, m *%s) error {
+	records, err := r.FindAll%s(ctx, m.%s)
+	if err != nil {
+		return err
+	}
+	m.%s = make([]%s, len(records))
+	for i, record := range records {
+		m.%s[i] = *record
+	}
+	return nil
+}
+
+`, repository, relation.Field, model, relation.Name, key.field, relation.Field, targetModel, relation.Field)
+	return nil
+}
+
+// keyColumns returns the columns identifying a record, the same ones the record statements of the dialects use.
+func keyColumns(table d.Table, columns []column) []column {
+	if len(table.PrimaryKeys) == 0 {
+		return columns[:1]
+	}
+	keys := make([]column, len(table.PrimaryKeys))
+	for i, pk := range table.PrimaryKeys {
+		keys[i] = *findColumn(columns, pk)
+	}
+	return keys
+}
+
+func findColumn(columns []column, name string) *column {
+	for i := range columns {
+		if columns[i].Name == name {
+			return &columns[i]
+		}
+	}
+	return nil
+}
+
+// parseImports returns the packages imported by the source file.
+func parseImports(filename string) (map[string]string, error) {
+	f, err := goparser.ParseFile(token.NewFileSet(), filename, nil, goparser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+	imports := map[string]string{} // map[name]importPath
+	for _, spec := range f.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		name := path.Base(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = p
+	}
+	return imports, nil
+}
+
+// qualify writes a type of the model file as it is written in the repository package, adding the import it needs.
+func qualify(goType string, modelPackage string, fileImports map[string]string, imports map[string]string) (string, error) {
+	prefix := goType[:len(goType)-len(strings.TrimLeft(goType, "*[]"))]
+	name := goType[len(prefix):]
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		p, ok := fileImports[name[:i]]
+		if !ok {
+			return "", fmt.Errorf("package of %s is not imported", name)
+		}
+		if path.Base(p) == name[:i] {
+			imports[p] = ""
+		} else {
+			imports[p] = name[:i]
+		}
+		return goType, nil
+	}
+	if types.Universe.Lookup(name) != nil {
+		return goType, nil
+	}
+	return prefix + modelPackage + "." + name, nil
+}
+
+// paramName returns the parameter name for a field, like id for ID and userID for UserID.
+func paramName(field string) string {
+	runes := []rune(field)
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+	if i > 1 && i < len(runes) {
+		// The last upper case letter starts the next word, like the C of HTTPCode.
+		i--
+	}
+	name := strings.ToLower(string(runes[:i])) + string(runes[i:])
+	if token.IsKeyword(name) || name == "ctx" || name == "r" {
+		return name + "_"
+	}
+	return name
+}
+
+// quote writes the statement as a raw string unless it has backquotes, like the identifiers of MySQL.
+func quote(statement string) string {
+	if strings.ContainsRune(statement, '`') {
+		return strconv.Quote(statement)
+	}
+	return "`" + statement + "`"
+}
+
+func lowerFirst(s string) string {
+	runes := []rune(s)
+	runes[0] = unicode.ToLower(runes[0])
+	name := string(runes)
+	if token.IsKeyword(name) || name == "m" || name == "err" || name == "rows" {
+		return name + "_"
+	}
+	return name
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	d "github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/sql"
+	"github.com/spf13/afero"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const modelsSource = `package models
+
+import "time"
+
+//+test
+type User struct {
+	ID        int64
+	Name      string ` + "`test:\"unique\"`" + `
+	Bio       *string
+	DeletedAt *time.Time
+}
+
+//+test
+type Micropost struct {
+	ID      int64
+	Author  User
+	Content string
+	Tag     []Tag
+}
+
+//+test
+type Tag struct {
+	ID   int64
+	Name string
+}
+`
+
+// TestNewRepositoriesBuild generates the repositories of a module and checks that they compile against its structs.
+func TestNewRepositoriesBuild(t *testing.T) {
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	for _, dialect := range []d.Dialect{d.NewMySQL(), d.NewPostgres(), d.NewSQLite()} {
+		dir := t.TempDir()
+		if err := os.Mkdir(filepath.Join(dir, "models"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		filename := filepath.Join(dir, "models", "models.go")
+		if err := os.WriteFile(filename, []byte(modelsSource), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		sqlMap, _, _, err := sql.CreateSQL(dialect, true, "+test", "test", []string{filename})
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := filepath.Join(dir, "repository")
+		r, err := NewRepositories(dialect, sqlMap, output, map[string]string{filename: "example.com/app/models"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{DBFile, "micropost_repository.go", "tag_repository.go", "user_repository.go"}; !reflect.DeepEqual(r.Order, want) {
+			t.Errorf("%T: files = %v, want %v", dialect, r.Order, want)
+		}
+		fs := afero.NewOsFs()
+		if err := r.WriteFile(&fs); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := exec.Command(goCmd, "vet", "./...")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod", "GOPROXY=off")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("%T: go vet: %v\n%s", dialect, err, out)
+		}
+	}
+}
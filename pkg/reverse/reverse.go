@@ -0,0 +1,158 @@
+package reverse
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/diff"
+	"github.com/naoina/go-stringutil"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Generate writes an annotated struct for each table, so that generating SQL from the structs gives back the same tables.
+// The result is a map of file names to formatted Go sources in the package.
+func Generate(d dialect.Dialect, tables []dialect.Table, packageName string, marker string) (files map[string][]byte, err error) {
+	files = map[string][]byte{}
+	for _, t := range tables {
+		src, err := generateTable(d, t, packageName, marker)
+		if err != nil {
+			return nil, fmt.Errorf("auto-table: %s: %v", t.Name, err)
+		}
+		files[stringutil.ToSnakeCase(goName(t.Name))+".go"] = src
+	}
+	return
+}
+
+func generateTable(d dialect.Dialect, t dialect.Table, packageName string, marker string) ([]byte, error) {
+	structName := goName(t.Name)
+	pks := map[string]struct{}{}
+	for _, pk := range t.PrimaryKeys {
+		pks[pk] = struct{}{}
+	}
+	fks := diff.NormalizeForeignKeys(t.ForeignKeys)
+
+	imports := map[string]struct{}{}
+	var fields bytes.Buffer
+	for _, f := range t.Fields {
+		if dialect.IsTimestampColumn(f.Name) {
+			continue
+		}
+		goType := d.GoType(f.Type, f.Nullable)
+		if goType == "interface{}" {
+			// A column of a type with no Go type is read as a string, and keeps its type by the type tag below.
+			goType = "string"
+		}
+		if pkg := d.ImportPackage(dialect.NewColumnSchema(t, f)); pkg != "" {
+			imports[pkg] = struct{}{}
+		}
+		fieldName := goName(f.Name)
+
+		var opts []string
+		if stringutil.ToSnakeCase(fieldName) != f.Name {
+			opts = append(opts, "column:"+f.Name)
+		}
+		if _, ok := pks[f.Name]; ok {
+			opts = append(opts, "pk")
+		}
+		if f.AutoIncrement {
+			opts = append(opts, "autoincrement")
+		}
+		if ref, ok := fks[f.Name]; ok {
+			opts = append(opts, fmt.Sprintf("fk:%s.%s", goName(ref.Table), goName(ref.Column)))
+		}
+		if !strings.EqualFold(d.ColumnType(strings.TrimLeft(goType, "*")), f.Type) {
+			opts = append(opts, "type:"+f.Type)
+		}
+		if f.Nullable && goType[0] != '*' && !d.IsNullable(goType) {
+			opts = append(opts, "null")
+		}
+		if f.Default != "" {
+			opts = append(opts, "default:"+f.Default)
+		}
+		if f.Extra != "" {
+			opts = append(opts, "extra:"+f.Extra)
+		}
+		opts = append(opts, indexOptions(t, f.Name)...)
+
+		fmt.Fprintf(&fields, "\t%s %s", fieldName, goType)
+		if len(opts) > 0 {
+			fmt.Fprintf(&fields, " %s", structTag(marker, strings.Join(opts, ",")))
+		}
+		if f.Comment != "" {
+			fmt.Fprintf(&fields, " // %s", strings.Join(strings.Fields(f.Comment), " "))
+		}
+		fields.WriteString("\n")
+	}
+
+	annotation := "//+" + marker
+	if stringutil.ToSnakeCase(structName) != t.Name {
+		annotation += " table:" + strconv.Quote(t.Name)
+	}
+	if t.Option != "" {
+		annotation += " option:" + strconv.Quote(t.Option)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "package %s\n\n", packageName)
+	if len(imports) > 0 {
+		pkgs := make([]string, 0, len(imports))
+		for pkg := range imports {
+			pkgs = append(pkgs, strconv.Quote(pkg))
+		}
+		sort.Strings(pkgs)
+		fmt.Fprintf(&src, "import (\n%s\n)\n\n", strings.Join(pkgs, "\n"))
+	}
+	fmt.Fprintf(&src, "%s\ntype %s struct {\n%s}\n", annotation, structName, fields.String())
+	return format.Source(src.Bytes())
+}
+
+// goName returns the exported Go identifier for a table or column name.
+// Characters that cannot appear in identifiers separate words like underscores do.
+func goName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+	name = stringutil.ToUpperCamelCase(name)
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// indexOptions returns the index and unique options of the column.
+// The name is left out when it is the one MakeIndexes gives a single column index by default.
+func indexOptions(t dialect.Table, column string) (opts []string) {
+	for _, idx := range t.Indexes {
+		for _, c := range idx.Columns {
+			if c != column {
+				continue
+			}
+			opt, prefix := "index", "idx"
+			if idx.Unique {
+				opt, prefix = "unique", "uq"
+			}
+			if len(idx.Columns) == 1 && idx.Name == fmt.Sprintf("%s_%s_%s", prefix, t.Name, column) {
+				opts = append(opts, opt)
+			} else {
+				opts = append(opts, opt+":"+idx.Name)
+			}
+		}
+	}
+	return
+}
+
+// structTag quotes the options as the tag of the marker.
+func structTag(marker string, value string) string {
+	tag := marker + ":" + strconv.Quote(value)
+	if strings.ContainsRune(tag, '`') {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
@@ -0,0 +1,72 @@
+package reverse
+
+import (
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/diff"
+	"github.com/hourglasshoro/auto-table/pkg/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestGenerateRoundTrip(t *testing.T) {
+	user := dialect.Table{
+		Name:        "user",
+		Fields:      []dialect.Field{{Table: "user", Name: "id", Type: "BIGINT", AutoIncrement: true}},
+		PrimaryKeys: []string{"id"},
+	}
+	profile := dialect.Table{
+		Name: "user_profile",
+		Fields: []dialect.Field{
+			{Table: "user_profile", Name: "id", Type: "BIGINT", AutoIncrement: true},
+			{Table: "user_profile", Name: "user_id", Type: "BIGINT"},
+			{Table: "user_profile", Name: "nick-name", Type: "VARCHAR(80)", Nullable: true, Default: "guest", Comment: "shown name"},
+			{Table: "user_profile", Name: "bio", Type: "TEXT"},
+			{Table: "user_profile", Name: "location", Type: "POINT"},
+		},
+		PrimaryKeys: []string{"id"},
+		ForeignKeys: map[string]dialect.ForeignKey{"user_id": {Table: "user", Column: "id"}},
+		Indexes: []dialect.Index{
+			{Table: "user_profile", Name: "profile_name", Columns: []string{"nick-name"}, Unique: true},
+			{Table: "user_profile", Name: "user_profile_user_id_bio_idx", Columns: []string{"user_id", "bio"}},
+		},
+		Option: "ENGINE=InnoDB",
+	}
+	tables := []dialect.Table{user, profile}
+
+	for _, d := range []dialect.Dialect{dialect.NewMySQL(), dialect.NewPostgres()} {
+		files, err := Generate(d, tables, "models", "db")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/models\n\ngo 1.22\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var filenames []string
+		for name, src := range files {
+			filename := filepath.Join(dir, name)
+			if err := os.WriteFile(filename, src, 0644); err != nil {
+				t.Fatal(err)
+			}
+			filenames = append(filenames, filename)
+		}
+		sort.Strings(filenames)
+		sqlMap, _, _, err := sql.CreateSQL(d, false, "+db", "db", filenames)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []dialect.Table
+		for _, s := range sqlMap {
+			got = append(got, s.Schema)
+		}
+		if td := diff.Compare(tables, got); !td.IsEmpty() {
+			for name, src := range files {
+				t.Logf("%s:\n%s", name, src)
+			}
+			t.Errorf("%T: the tables of the generated structs differ: %+v", d, td)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package runner
+
+import (
+	"context"
+	gosql "database/sql"
+	"fmt"
+	d "github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/migration"
+	"sort"
+	"strings"
+)
+
+const (
+	// TableName is the table the applied migrations are recorded in.
+	TableName = "schema_migrations"
+
+	// lockName is the advisory lock held while migrations run, so that concurrent deploys cannot apply the same migration twice.
+	lockName = "auto-table"
+)
+
+// AppliedMigration is a migration recorded in the schema_migrations table.
+type AppliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt string
+}
+
+// Runner applies the migrations in a directory to a database.
+type Runner struct {
+	dialect  d.Dialect
+	migrator d.Migrator
+	db       *gosql.DB
+	migrates *migration.Migrates
+	table    d.Table
+}
+
+// New creates a runner for the migrations. The dialect has to implement dialect.Migrator.
+func New(dialect d.Dialect, db *gosql.DB, migrates *migration.Migrates) (*Runner, error) {
+	migrator, ok := dialect.(d.Migrator)
+	if !ok {
+		return nil, fmt.Errorf("auto-table: this dialect cannot apply migrations")
+	}
+	return &Runner{
+		dialect:  dialect,
+		migrator: migrator,
+		db:       db,
+		migrates: migrates,
+		table: d.Table{
+			Name: TableName,
+			Fields: []d.Field{
+				{Table: TableName, Name: "version", Type: dialect.ColumnType("int64")},
+				{Table: TableName, Name: "name", Type: dialect.ColumnType("string")},
+				{Table: TableName, Name: "checksum", Type: dialect.ColumnType("string")},
+			},
+			PrimaryKeys: []string{"version"},
+		},
+	}, nil
+}
+
+// Up applies up to n pending migrations in order, or all of them if n is not positive.
+func (r *Runner) Up(n int) (applied []*migration.Migrate, err error) {
+	err = r.withLock(func(conn *gosql.Conn) error {
+		done, err := r.applied(conn)
+		if err != nil {
+			return err
+		}
+		for _, key := range r.migrates.Order {
+			if n > 0 && len(applied) >= n {
+				break
+			}
+			m := r.migrates.Map[key]
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+			insert := strings.Join(r.dialect.CreateSQL(r.table), "\n")
+			if err := r.run(conn, m.Up, insert, m.Version, m.Name, migration.Checksum(m.Up.SQL)); err != nil {
+				return err
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	return
+}
+
+// Down reverts up to n applied migrations, newest first, or all of them if n is not positive.
+func (r *Runner) Down(n int) (reverted []*migration.Migrate, err error) {
+	versions := map[int64]*migration.Migrate{}
+	for _, key := range r.migrates.Order {
+		m := r.migrates.Map[key]
+		versions[m.Version] = m
+	}
+	err = r.withLock(func(conn *gosql.Conn) error {
+		done, err := r.applied(conn)
+		if err != nil {
+			return err
+		}
+		for _, a := range sortApplied(done, true) {
+			if n > 0 && len(reverted) >= n {
+				break
+			}
+			m, ok := versions[a.Version]
+			if !ok {
+				return fmt.Errorf("auto-table: migration %d_%s is applied but missing on disk", a.Version, a.Name)
+			}
+			if m.Down == nil {
+				return fmt.Errorf("auto-table: %d_%s has no down migration", m.Version, m.Name)
+			}
+			remove := strings.Join(r.dialect.DeleteSQL(r.table), "\n")
+			if err := r.run(conn, m.Down, remove, m.Version); err != nil {
+				return err
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	return
+}
+
+// Applied returns the migrations recorded in the schema_migrations table, oldest first.
+// It only reads the database: the lock is not taken, and no migration is applied if the table does not exist.
+func (r *Runner) Applied() (applied []*AppliedMigration, err error) {
+	ctx := context.Background()
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	exists, err := r.migrator.HasTable(conn, r.table.Name)
+	if err != nil || !exists {
+		return
+	}
+	done, err := r.applied(conn)
+	if err != nil {
+		return
+	}
+	applied = sortApplied(done, false)
+	return
+}
+
+// withLock runs f on a single connection while holding the advisory lock, after making sure the schema_migrations table exists.
+func (r *Runner) withLock(f func(conn *gosql.Conn) error) (err error) {
+	ctx := context.Background()
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if err = r.migrator.Lock(conn, lockName); err != nil {
+		return
+	}
+	defer func() {
+		if uErr := r.migrator.Unlock(conn, lockName); uErr != nil && err == nil {
+			err = uErr
+		}
+	}()
+
+	for _, query := range migration.SplitStatements(strings.Join(r.dialect.CreateTableSQL(r.table), "\n")) {
+		if _, err = conn.ExecContext(ctx, query); err != nil {
+			return
+		}
+	}
+	return f(conn)
+}
+
+// applied reads the schema_migrations table.
+func (r *Runner) applied(conn *gosql.Conn) (map[int64]*AppliedMigration, error) {
+	query := strings.TrimSuffix(strings.Join(r.dialect.FindAllSQL(r.table), "\n"), ";")
+	rows, err := conn.QueryContext(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]*AppliedMigration{}
+	for rows.Next() {
+		var a AppliedMigration
+		var createdAt, updatedAt gosql.NullString
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		a.AppliedAt = createdAt.String
+		applied[a.Version] = &a
+	}
+	return applied, rows.Err()
+}
+
+// run executes the statements of a migration file and records the result with bookkeeping.
+// The statements share a transaction with the bookkeeping when the dialect can roll back schema changes,
+// and otherwise run one by one on the connection, since a transaction would not undo them anyway.
+// Statements changing the session at the start and the end of the file run on the connection before and after the transaction,
+// the ones at the end even if the migration fails, so that the connection is left as it was.
+func (r *Runner) run(conn *gosql.Conn, elm *migration.MigrateElm, bookkeeping string, args ...interface{}) (err error) {
+	before, queries, after := r.sessionStatements(migration.SplitStatements(elm.SQL))
+	ctx := context.Background()
+	for _, query := range before {
+		if _, err = conn.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("auto-table: %s: %v", elm.File, err)
+		}
+	}
+	defer func() {
+		for _, query := range after {
+			if _, aErr := conn.ExecContext(ctx, query); aErr != nil && err == nil {
+				err = fmt.Errorf("auto-table: %s: %v", elm.File, aErr)
+			}
+		}
+	}()
+
+	if !r.migrator.TransactionalDDL() {
+		for _, query := range queries {
+			if _, err = conn.ExecContext(ctx, query); err != nil {
+				return fmt.Errorf("auto-table: %s: %v", elm.File, err)
+			}
+		}
+		_, err = conn.ExecContext(ctx, strings.TrimSuffix(bookkeeping, ";"), args...)
+		return
+	}
+
+	tx, err := r.migrator.Begin(conn)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, query := range queries {
+		if err = tx.Exec(query); err != nil {
+			return fmt.Errorf("auto-table: %s: %v", elm.File, err)
+		}
+	}
+	if err = tx.Exec(strings.TrimSuffix(bookkeeping, ";"), args...); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// sessionStatements splits the statements changing the session at the start and the end off the statements of a migration.
+func (r *Runner) sessionStatements(queries []string) (before []string, rest []string, after []string) {
+	modifier, ok := r.dialect.(d.SessionModifier)
+	if !ok {
+		return nil, queries, nil
+	}
+	for len(queries) > 0 && modifier.IsSessionStatement(queries[0]) {
+		before, queries = append(before, queries[0]), queries[1:]
+	}
+	for len(queries) > 0 && modifier.IsSessionStatement(queries[len(queries)-1]) {
+		after, queries = append([]string{queries[len(queries)-1]}, after...), queries[:len(queries)-1]
+	}
+	return before, queries, after
+}
+
+func sortApplied(applied map[int64]*AppliedMigration, reverse bool) []*AppliedMigration {
+	sorted := make([]*AppliedMigration, 0, len(applied))
+	for _, a := range applied {
+		sorted = append(sorted, a)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if reverse {
+			return sorted[i].Version > sorted[j].Version
+		}
+		return sorted[i].Version < sorted[j].Version
+	})
+	return sorted
+}
@@ -0,0 +1,186 @@
+package runner
+
+import (
+	gosql "database/sql"
+	"fmt"
+	d "github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/migration"
+	"github.com/spf13/afero"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	createUser = "CREATE TABLE \"user\" (\"id\" INTEGER PRIMARY KEY);\n"
+	dropUser   = "DROP TABLE \"user\";\n"
+	createPost = "CREATE TABLE \"post\" (\"id\" INTEGER PRIMARY KEY, \"user_id\" INTEGER REFERENCES \"user\"(\"id\"));\n"
+	dropPost   = "DROP TABLE \"post\";\n"
+)
+
+// newRunner writes the migration files, given as map[fileName]SQL, and opens a runner on a new SQLite database.
+func newRunner(t *testing.T, files map[string]string) (*Runner, *gosql.DB) {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	for name, sql := range files {
+		if err := afero.WriteFile(fs, filepath.Join("migrations", name), []byte(sql), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	migrates, err := migration.ReadDir(&fs, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gosql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// A single connection, so that the session the migrations ran in can be checked
+	db.SetMaxOpenConns(1)
+	r, err := New(d.NewSQLite(), db, migrates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, db
+}
+
+func versions(migrates []*migration.Migrate) (versions []int64) {
+	for _, m := range migrates {
+		versions = append(versions, m.Version)
+	}
+	return
+}
+
+func appliedVersions(t *testing.T, r *Runner) (versions []int64) {
+	t.Helper()
+	applied, err := r.Applied()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range applied {
+		versions = append(versions, a.Version)
+	}
+	return
+}
+
+func hasTable(t *testing.T, db *gosql.DB, name string) bool {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n > 0
+}
+
+func TestUpDown(t *testing.T) {
+	r, db := newRunner(t, map[string]string{
+		"1_add_user_table.up.sql":   createUser,
+		"1_add_user_table.down.sql": dropUser,
+		"2_add_post_table.up.sql":   createPost,
+		"2_add_post_table.down.sql": dropPost,
+	})
+
+	steps := []struct {
+		name    string
+		run     func() ([]*migration.Migrate, error)
+		want    []int64
+		applied []int64
+	}{
+		{name: "up one", run: func() ([]*migration.Migrate, error) { return r.Up(1) }, want: []int64{1}, applied: []int64{1}},
+		{name: "up all", run: func() ([]*migration.Migrate, error) { return r.Up(0) }, want: []int64{2}, applied: []int64{1, 2}},
+		{name: "up nothing pending", run: func() ([]*migration.Migrate, error) { return r.Up(0) }, applied: []int64{1, 2}},
+		{name: "down one", run: func() ([]*migration.Migrate, error) { return r.Down(1) }, want: []int64{2}, applied: []int64{1}},
+	}
+	for _, s := range steps {
+		migrates, err := s.run()
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if got := versions(migrates); !reflect.DeepEqual(got, s.want) {
+			t.Errorf("%s: ran %v, want %v", s.name, got, s.want)
+		}
+		if got := appliedVersions(t, r); !reflect.DeepEqual(got, s.applied) {
+			t.Errorf("%s: applied %v, want %v", s.name, got, s.applied)
+		}
+	}
+	if !hasTable(t, db, "user") || hasTable(t, db, "post") {
+		t.Error("user should exist and post should be dropped")
+	}
+	if hasTable(t, db, "auto_table_locks") {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM "auto_table_locks"`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != 0 {
+			t.Errorf("%d locks are left behind", n)
+		}
+	}
+}
+
+func TestUpRollsBackFailedMigration(t *testing.T) {
+	r, db := newRunner(t, map[string]string{
+		"1_add_user_table.up.sql": createUser,
+		"2_add_post_table.up.sql": createPost + "INSERT INTO \"nope\" VALUES (1);\n",
+	})
+	if _, err := r.Up(0); err == nil {
+		t.Fatal("Up() succeeded, want the error of 2_add_post_table")
+	}
+	if got, want := appliedVersions(t, r), []int64{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("applied %v, want %v", got, want)
+	}
+	if hasTable(t, db, "post") {
+		t.Error("post was created by the failed migration")
+	}
+}
+
+func TestUpSessionStatements(t *testing.T) {
+	rebuild := "PRAGMA foreign_keys = OFF;\n" +
+		"CREATE TABLE \"_user_new\" (\"id\" INTEGER PRIMARY KEY, \"name\" TEXT);\n" +
+		"INSERT INTO \"_user_new\" (\"id\") SELECT \"id\" FROM \"user\";\n" +
+		"DROP TABLE \"user\";\n" +
+		"ALTER TABLE \"_user_new\" RENAME TO \"user\";\n" +
+		"%s" +
+		"PRAGMA foreign_keys = ON;\n"
+	tests := []struct {
+		name    string
+		failure string
+	}{
+		{name: "applied"},
+		{name: "failed", failure: "INSERT INTO \"nope\" VALUES (1);\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, db := newRunner(t, map[string]string{
+				"1_add_user_table.up.sql":   createUser + createPost,
+				"2_alter_user_table.up.sql": fmt.Sprintf(rebuild, tt.failure),
+			})
+			if _, err := r.Up(1); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := db.Exec(`INSERT INTO "user" ("id") VALUES (1); INSERT INTO "post" ("id", "user_id") VALUES (1, 1)`); err != nil {
+				t.Fatal(err)
+			}
+			_, err := r.Up(0)
+			if (err != nil) != (tt.failure != "") {
+				t.Fatalf("Up() error = %v", err)
+			}
+			var on int
+			if err := db.QueryRow("PRAGMA foreign_keys").Scan(&on); err != nil {
+				t.Fatal(err)
+			}
+			if on != 1 {
+				t.Error("foreign keys are left off")
+			}
+			var n int
+			if err := db.QueryRow(`SELECT COUNT(*) FROM "post" JOIN "user" ON "user"."id" = "post"."user_id"`).Scan(&n); err != nil {
+				t.Fatal(err)
+			}
+			if n != 1 {
+				t.Error("the post lost its user")
+			}
+		})
+	}
+}
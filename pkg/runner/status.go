@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"github.com/hourglasshoro/auto-table/pkg/migration"
+	"sort"
+)
+
+const (
+	// StatusApplied is a migration file that is recorded in the schema_migrations table.
+	StatusApplied = "applied"
+	// StatusPending is a migration file that has not been applied yet.
+	StatusPending = "pending"
+	// StatusMissing is a migration that is recorded in the schema_migrations table but has no file on disk.
+	StatusMissing = "missing"
+)
+
+// MigrationStatus is the state of a single migration.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	File      string // Empty if the migration is missing on disk
+	Status    string
+	AppliedAt string
+	// Modified reports whether the up file changed after it was applied.
+	Modified bool
+}
+
+// Status compares the migration files with the schema_migrations table. The result is ordered by version.
+func (r *Runner) Status() (statuses []*MigrationStatus, err error) {
+	applied, err := r.Applied()
+	if err != nil {
+		return
+	}
+	done := map[int64]*AppliedMigration{}
+	for _, a := range applied {
+		done[a.Version] = a
+	}
+
+	files := map[int64]bool{}
+	for _, key := range r.migrates.Order {
+		m := r.migrates.Map[key]
+		files[m.Version] = true
+		s := &MigrationStatus{
+			Version: m.Version,
+			Name:    m.Name,
+			File:    m.Up.File,
+			Status:  StatusPending,
+		}
+		if a, ok := done[m.Version]; ok {
+			s.Status = StatusApplied
+			s.AppliedAt = a.AppliedAt
+			s.Modified = a.Checksum != migration.Checksum(m.Up.SQL)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, a := range applied {
+		if files[a.Version] {
+			continue
+		}
+		statuses = append(statuses, &MigrationStatus{
+			Version:   a.Version,
+			Name:      a.Name,
+			Status:    StatusMissing,
+			AppliedAt: a.AppliedAt,
+		})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return
+}
@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	r, db := newRunner(t, map[string]string{
+		"1_add_user_table.up.sql": createUser,
+		"2_add_post_table.up.sql": createPost,
+		"3_add_tag_table.up.sql":  "CREATE TABLE \"tag\" (\"id\" INTEGER PRIMARY KEY);\n",
+	})
+	status := func() (got []string) {
+		t.Helper()
+		statuses, err := r.Status()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range statuses {
+			state := s.Status
+			if s.Modified {
+				state += " modified"
+			}
+			if s.Status != StatusPending && s.AppliedAt == "" {
+				state += " without applied time"
+			}
+			got = append(got, s.Name+" "+s.File+" "+state)
+		}
+		return
+	}
+
+	want := []string{
+		"add_user_table 1_add_user_table.up.sql pending",
+		"add_post_table 2_add_post_table.up.sql pending",
+		"add_tag_table 3_add_tag_table.up.sql pending",
+	}
+	if got := status(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Status() before up = %q, want %q", got, want)
+	}
+	if hasTable(t, db, TableName) {
+		t.Errorf("Status() created %s", TableName)
+	}
+
+	if _, err := r.Up(2); err != nil {
+		t.Fatal(err)
+	}
+	r.migrates.Map["1_add_user_table"].Up.SQL += "CREATE INDEX \"user_id_idx\" ON \"user\" (\"id\");\n"
+	if _, err := db.Exec(`INSERT INTO "schema_migrations" ("version", "name", "checksum") VALUES (4, 'add_like_table', '')`); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		"add_user_table 1_add_user_table.up.sql applied modified",
+		"add_post_table 2_add_post_table.up.sql applied",
+		"add_tag_table 3_add_tag_table.up.sql pending",
+		"add_like_table  missing",
+	}
+	if got := status(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Status() after up = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/diff"
+	"github.com/hourglasshoro/auto-table/pkg/sql"
+	"github.com/spf13/afero"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// DefaultFilename is the name of the snapshot written next to the migrations.
+	DefaultFilename = "schema.json"
+
+	// FormatVersion is the version of the snapshot format written by this package.
+	FormatVersion = 1
+)
+
+// Snapshot is the fully resolved schema of a generation, used as the baseline of the next diff.
+type Snapshot struct {
+	Version int     `json:"version"`
+	Tables  []Table `json:"tables"`
+}
+
+// Table is a resolved table together with where it is declared.
+type Table struct {
+	dialect.Table
+	Struct string `json:"struct,omitempty"` // Empty for cross reference tables
+	File   string `json:"file,omitempty"`   // Relative to the source directory
+}
+
+// New creates a snapshot of the tables the SQL statements were generated from.
+// Foreign keys are recorded by column and table names, like the tables of a live database.
+// Source files are recorded relative to sourceDir so that the snapshot does not depend on where the repository is checked out.
+func New(sqlMap map[string]*sql.SQL, sourceDir string) *Snapshot {
+	names := make([]string, 0, len(sqlMap))
+	for name := range sqlMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s := &Snapshot{
+		Version: FormatVersion,
+		Tables:  make([]Table, len(names)),
+	}
+	for i, name := range names {
+		src := sqlMap[name].Source
+		filename := src.Filename
+		if rel, err := filepath.Rel(sourceDir, filename); err == nil && filename != "" {
+			filename = filepath.ToSlash(rel)
+		}
+		table := sqlMap[name].Schema
+		table.ForeignKeys = diff.NormalizeForeignKeys(table.ForeignKeys)
+		s.Tables[i] = Table{
+			Table:  table,
+			Struct: src.Struct,
+			File:   filename,
+		}
+	}
+	return s
+}
+
+// FromTables creates a snapshot of tables that are not declared by structs, like the tables of a live database.
+func FromTables(tables []dialect.Table) *Snapshot {
+	s := &Snapshot{
+		Version: FormatVersion,
+		Tables:  make([]Table, len(tables)),
+	}
+	for i, t := range tables {
+		s.Tables[i] = Table{Table: t}
+	}
+	sort.Slice(s.Tables, func(i, j int) bool { return s.Tables[i].Name < s.Tables[j].Name })
+	return s
+}
+
+// Read reads the snapshot from the file. An empty snapshot is returned if the file does not exist yet.
+func Read(fs *afero.Fs, filename string) (*Snapshot, error) {
+	b, err := afero.ReadFile(*fs, filename)
+	if os.IsNotExist(err) {
+		return &Snapshot{Version: FormatVersion}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(b)
+}
+
+// Unmarshal parses a snapshot.
+func Unmarshal(b []byte) (*Snapshot, error) {
+	s := &Snapshot{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	if s.Version > FormatVersion {
+		return nil, fmt.Errorf("auto-table: unsupported snapshot version %d", s.Version)
+	}
+	return s, nil
+}
+
+// Marshal encodes the snapshot as indented JSON.
+func (s *Snapshot) Marshal() ([]byte, error) {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Write writes the snapshot to the file.
+func (s *Snapshot) Write(fs *afero.Fs, filename string) error {
+	b, err := s.Marshal()
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(*fs, filename, b, 0644)
+}
+
+// Schema returns the tables of the snapshot without their sources.
+func (s *Snapshot) Schema() []dialect.Table {
+	tables := make([]dialect.Table, len(s.Tables))
+	for i, t := range s.Tables {
+		tables[i] = t.Table
+	}
+	return tables
+}
@@ -0,0 +1,77 @@
+package snapshot
+
+import (
+	"github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/sql"
+	"github.com/spf13/afero"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	sourceDir := filepath.Join("/src", "app")
+	sqlMap := map[string]*sql.SQL{
+		"user": {
+			Schema: dialect.Table{
+				Name:        "user",
+				Fields:      []dialect.Field{{Table: "user", Name: "id", Type: "BIGINT", AutoIncrement: true}},
+				PrimaryKeys: []string{"id"},
+			},
+			Source: sql.Source{Struct: "User", Filename: filepath.Join(sourceDir, "domain", "user.go")},
+		},
+		"micropost": {
+			Schema: dialect.Table{
+				Name: "micropost",
+				Fields: []dialect.Field{
+					{Table: "micropost", Name: "id", Type: "BIGINT", AutoIncrement: true},
+					{Table: "micropost", Name: "author_id", Type: "BIGINT"},
+				},
+				PrimaryKeys: []string{"id"},
+				ForeignKeys: map[string]dialect.ForeignKey{"AuthorID": {Table: "User", Column: "ID"}},
+				Indexes:     []dialect.Index{{Table: "micropost", Name: "micropost_author_id_idx", Columns: []string{"author_id"}}},
+			},
+			Source: sql.Source{Struct: "Micropost", Filename: filepath.Join(sourceDir, "domain", "micropost.go")},
+		},
+	}
+
+	fs := afero.NewMemMapFs()
+	filename := filepath.Join("migrations", DefaultFilename)
+	if err := New(sqlMap, sourceDir).Write(&fs, filename); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&fs, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	micropost := sqlMap["micropost"].Schema
+	micropost.ForeignKeys = map[string]dialect.ForeignKey{"author_id": {Table: "user", Column: "id"}}
+	want := &Snapshot{
+		Version: FormatVersion,
+		Tables: []Table{
+			{Table: micropost, Struct: "Micropost", File: "domain/micropost.go"},
+			{Table: sqlMap["user"].Schema, Struct: "User", File: "domain/user.go"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadMissingFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	got, err := Read(&fs, DefaultFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Snapshot{Version: FormatVersion}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+}
+
+func TestUnmarshalNewerVersion(t *testing.T) {
+	if _, err := Unmarshal([]byte(`{"version": 2, "tables": []}`)); err == nil {
+		t.Error("Unmarshal() of a newer version succeeded, want an error")
+	}
+}
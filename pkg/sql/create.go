@@ -1,18 +1,23 @@
 package sql
 
 import (
-	"errors"
 	"fmt"
 	"github.com/hourglasshoro/auto-table/pkg/ast"
+	"github.com/hourglasshoro/auto-table/pkg/diagnostic"
 	d "github.com/hourglasshoro/auto-table/pkg/dialect"
 	"github.com/naoina/go-stringutil"
 	goast "go/ast"
-	"log"
+	"go/token"
+	"go/types"
+	"sort"
 	"strings"
 )
 
 const idCandidate = "id"
 
+// BatchRows is the number of records inserted at once by Record.BatchCreate.
+const BatchRows = 10
+
 var intPrimitives = map[string]struct{}{"int8": {}, "int16": {}, "int32": {}, "int64": {}, "int": {}, "uint8": {}, "uint16": {}, "uint32": {}, "uint64": {}, "uint": {}}
 
 type Table struct {
@@ -26,24 +31,58 @@ type Record struct {
 	Create  string
 	Delete  string
 	Update  string
+
+	// The statements below are empty if the dialect does not implement dialect.RecordQuerier.
+	Upsert      string
+	BatchCreate string
+	Paginate    string
+	FindBy      map[string]string // map[indexName]statement, for unique indexes
+}
+
+// Source is where the table is declared.
+type Source struct {
+	Struct   string
+	Filename string
+	Pos      token.Position // Position of the struct, or of the struct owning the cross reference
+	Package  string
+
+	// Slice field of the struct holding the records whose foreign key references the table itself, like Children []Category
+	Children     string
+	ChildrenType string
 }
 
 type SQL struct {
-	Table  Table
-	Record Record
+	Schema    d.Table      // Resolved table structure the statements were generated from
+	Fields    []*ast.Field // Struct fields of Schema.Fields, in the same order
+	Source    Source
+	Table     Table
+	Record    Record
+	Relations []*Relation // Statements reading the records related by foreign keys, empty if the dialect does not implement dialect.RelationQuerier
 }
 
 // CreateSQL creates SQL statements from files.
-func CreateSQL(dialect d.Dialect, isAutoID bool, marker string, tagMarker string, filenames []string) (sqlMap map[string]*SQL, dependencyMap map[string]map[string]struct{}, err error) {
-	tableASTMap, tableNames, dependencyMap, err := makeTableASTMap(dialect, isAutoID, marker, tagMarker, filenames)
+// The diagnostics hold the fields that were left out, with their positions. If any of them is an error, err is the diagnostic.Errors of them.
+func CreateSQL(dialect d.Dialect, isAutoID bool, marker string, tagMarker string, filenames []string) (sqlMap map[string]*SQL, dependencyMap map[string]map[string]struct{}, diags diagnostic.List, err error) {
+	tableASTMap, tableNames, dependencyMap, diags, err := makeTableASTMap(dialect, isAutoID, marker, tagMarker, filenames)
+	if err != nil {
+		return
+	}
+	diags.Sort()
+	err = diags.Err()
+	if err != nil {
+		return
+	}
 	sqlMap = makeSQLMap(dialect, tableASTMap, tableNames)
 	return
 }
 
 // makeTableASTMap create own table structure from a file
-func makeTableASTMap(dialect d.Dialect, isAutoID bool, marker string, tagMarker string, filenames []string) (tableASTMap map[string]*ast.Table, tableNames []string, dependencyMap map[string]map[string]struct{}, err error) {
+func makeTableASTMap(dialect d.Dialect, isAutoID bool, marker string, tagMarker string, filenames []string) (tableASTMap map[string]*ast.Table, tableNames []string, dependencyMap map[string]map[string]struct{}, diags diagnostic.List, err error) {
 
-	modelASTMap, err := parseFileToASTMap(filenames, marker)
+	modelASTMap, diags, err := parseFileToASTMap(filenames, marker)
+	if err != nil {
+		return
+	}
 
 	tableASTMap = map[string]*ast.Table{} // map [tableName]details
 
@@ -51,59 +90,89 @@ func makeTableASTMap(dialect d.Dialect, isAutoID bool, marker string, tagMarker
 	// Keeps track of whether one table depends on another table. All tables are stored in the key of the first map. The value stores which tables depend on it.
 	dependencyMap = map[string]map[string]struct{}{} // map[tableName]dependency
 
-	for modelName, StructAST := range modelASTMap {
+	foreignKeyPositions := map[*ast.Field]token.Position{} // map[field]position, of the fields with foreign keys
+
+	// Structs are read in name order, so that the tables come out the same on every run.
+	modelNames := make([]string, 0, len(modelASTMap))
+	for modelName := range modelASTMap {
+		modelNames = append(modelNames, modelName)
+	}
+	sort.Strings(modelNames)
+
+	for _, modelName := range modelNames {
+		StructAST := modelASTMap[modelName]
 
 		var hasID bool    // Whether or not this struct has an ID field
 		var idType string // Type of ID field
 		dependencyMap[modelName] = map[string]struct{}{}
 
-		for _, fld := range StructAST.StructType.Fields.List {
-			field, newHasID, newIDType, tErr := makeField(tagMarker, dialect, modelASTMap, tableASTMap, dependencyMap, modelName, fld, isAutoID, hasID, idType)
+		for _, sf := range structFields(tagMarker, modelName, StructAST, &diags) {
+			field, newHasID, newIDType, tErr := makeField(tagMarker, dialect, modelASTMap, tableASTMap, dependencyMap, modelName, sf, isAutoID, hasID, idType)
 			if tErr != nil {
-				log.Print(tErr)
+				diags.Report(sf.owner.Position(sf.field), tErr)
 				continue
 			}
 			hasID = newHasID
 			idType = newIDType
+			if field == nil {
+				continue
+			}
+			if field.ForeignKey != nil {
+				foreignKeyPositions[field] = sf.owner.Position(sf.field)
+			}
 
 			if tableASTMap[modelName] == nil {
 				tableASTMap[modelName] = &ast.Table{
-					Option: StructAST.Annotation.Option,
+					Option:   StructAST.Annotation.Option,
+					Struct:   StructAST.Name,
+					Filename: StructAST.Filename,
+					Pos:      StructAST.Position(StructAST.StructType),
+					Package:  StructAST.Package,
 				}
+				_, tableASTMap[modelName].Children, tableASTMap[modelName].ChildrenType = StructAST.SelfReference()
 			}
 			tableASTMap[modelName].Fields = append(tableASTMap[modelName].Fields, field)
 		}
 
 	}
 
+	// Foreign keys declared by tags depend on their tables too.
+	for name, tbl := range tableASTMap {
+		for _, f := range tbl.Fields {
+			if f.ForeignKey == nil {
+				continue
+			}
+			parent := stringutil.ToSnakeCase(f.ForeignKey.Table)
+			if _, ok := tableASTMap[parent]; !ok {
+				if !hasModel(modelASTMap, f.ForeignKey.Table) {
+					diags.Report(foreignKeyPositions[f], fmt.Errorf("%s.%s references %s.%s, which is not a table", name, f.Name, f.ForeignKey.Table, f.ForeignKey.Column))
+				}
+				continue
+			}
+			if parent != name {
+				dependencyMap[name][parent] = struct{}{}
+			}
+		}
+	}
+
 	// Get table names
 	tableNames = make([]string, 0, len(tableASTMap))
 	for name := range tableASTMap {
 		tableNames = append(tableNames, name)
 	}
+	sort.Strings(tableNames)
 
 	return
 }
 
-// parseFileToASTMap parses from file to ast.StructAST
-func parseFileToASTMap(filenames []string, marker string) (modelASTMap map[string]*ast.StructAST, err error) {
-	modelASTMap = make(map[string]*ast.StructAST)
-
-	for _, filename := range filenames {
-		m, tErr := ast.MakeStructASTMap(filename, marker)
-		if tErr != nil {
-			err = tErr
-			return
-		}
-		for k, v := range m {
-			modelASTMap[k] = v
-		}
-	}
-
-	return
+// parseFileToASTMap parses from file to ast.StructAST, type checking the packages of the files if they can be loaded.
+// The diagnostics warn of the files that could not be.
+func parseFileToASTMap(filenames []string, marker string) (modelASTMap map[string]*ast.StructAST, diags diagnostic.List, err error) {
+	return ast.LoadStructASTMap(filenames, marker)
 }
 
-// makeField gets its own Field structure from ast.StructAST
+// makeField gets its own Field structure from ast.StructAST.
+// The field is nil if the struct field makes no column, like a slice of children whose foreign key is made from the pointer to the parent.
 func makeField(
 	tagMarker string,
 	dialect d.Dialect,
@@ -111,12 +180,23 @@ func makeField(
 	tableASTMap map[string]*ast.Table, // With side effects
 	dependencyMap map[string]map[string]struct{}, // With side effects
 	modelName string,
-	fld *goast.Field,
+	sf structField,
 	isAutoID bool,
 	hasID bool,
 	idType string,
 ) (field *ast.Field, newHasID bool, newIDType string, err error) {
-	typeStr, typeName, _, isArray, err := ast.DetectTypeName(fld)
+	fld := sf.field
+	// The column is made from the resolved type, like string for type UserID string, and the struct field keeps the declared type.
+	var typeStr, typeName, pkgPath string
+	var isPtr, isArray bool
+	declared, _, _, _, err := ast.DetectTypeName(fld)
+	if err == nil {
+		typeStr, typeName, pkgPath, isPtr, isArray, err = sf.owner.DetectType(fld.Type)
+	}
+	if err != nil {
+		err = diagnostic.Warnf("%s.%s is left out: %s cannot be a column", modelName, fld.Names[0].Name, types.ExprString(fld.Type))
+		return
+	}
 	isPrimaryKey, isAutoIncrement, tHasID, tIDType, tErr := autoMakePrimaryFromID(isAutoID, fld, typeStr)
 	if tErr != nil {
 		err = tErr
@@ -127,7 +207,7 @@ func makeField(
 	if hasID || tHasID {
 		if tHasID {
 			if idType != "" {
-				err = errors.New("multiple IDs exist")
+				err = fmt.Errorf("%s has multiple IDs", modelName)
 				return
 			}
 			newHasID = tHasID
@@ -138,7 +218,7 @@ func makeField(
 		}
 	}
 
-	newTypeStr, fieldName, foreignKey, tErr := autoMakeForeignRelation(tagMarker, dialect, modelASTMap, tableASTMap, dependencyMap, modelName, fld, typeName, isArray, newHasID, newIDType)
+	newTypeStr, fieldName, foreignKey, skip, tErr := autoMakeForeignRelation(tagMarker, dialect, modelASTMap, tableASTMap, dependencyMap, modelName, fld, typeName, pkgPath, isPtr, isArray, newHasID, newIDType)
 	if newTypeStr != "" {
 		typeStr = newTypeStr
 	}
@@ -146,24 +226,121 @@ func makeField(
 		err = tErr
 		return
 	}
+	if skip {
+		return
+	}
 
 	field, err = ast.NewField(tagMarker, dialect, modelName, typeStr, fieldName, fld, foreignKey, isPrimaryKey, isAutoIncrement)
 	if err != nil {
 		return
 	}
+	if newTypeStr == "" {
+		field.GoType = declared
+	}
+	field.Column = sf.prefix + field.Column
 	if field.Ignore {
-		err = fmt.Errorf("this field will be ignored: %s.%s", modelName, field.Name)
+		err = diagnostic.Skipf("%s.%s is left out: it is tagged to be ignored", modelName, field.Name)
 		return
 	}
 
 	if !(goast.IsExported(field.Name) || (field.Name == "_" && field.Name != field.Column)) {
-		err = fmt.Errorf("this field has not been exported: %s.%s", modelName, field.Name)
+		err = diagnostic.Skipf("%s.%s is left out: it is not exported", modelName, field.Name)
+		return
+	}
+
+	if d.IsTimestampColumn(field.Column) {
+		err = diagnostic.Skipf("%s.%s is left out: the %s column is added to every table", modelName, field.Name, field.Column)
 		return
 	}
 
 	return
 }
 
+// structField is a field of a struct, or of a struct embedded by it.
+type structField struct {
+	owner  *ast.StructAST // Struct declaring the field
+	field  *goast.Field
+	prefix string // Prefix of the column, from the prefix tags of the fields embedding the owner
+	depth  int    // Number of embedded structs the field is promoted through
+}
+
+// structFields flattens the fields of a struct, replacing each embedded struct by its fields like Go promotes them.
+// A field is left out if a field of the same name is less deep, or equally deep in another embedded struct.
+// Problems with the embedded structs are reported to diags if it is not nil.
+func structFields(tagMarker string, modelName string, s *ast.StructAST, diags *diagnostic.List) []structField {
+	report := func(owner *ast.StructAST, fld *goast.Field, err error) {
+		if diags != nil {
+			diags.Report(owner.Position(fld), err)
+		}
+	}
+
+	var fields []structField
+	depths := map[string][]int{} // map[fieldName]depths, of the embedded structs too as they are fields named after their types
+	// Structs being flattened, so that a struct embedding itself by a pointer ends.
+	// They are keyed by their declarations, as structs found without type checking are parsed again each time.
+	type declaration struct{ filename, name string }
+	embedding := map[declaration]bool{{s.Filename, s.Name}: true}
+	var flatten func(owner *ast.StructAST, prefix string, depth int)
+	flatten = func(owner *ast.StructAST, prefix string, depth int) {
+		for _, fld := range owner.StructType.Fields.List {
+			if len(fld.Names) > 0 {
+				fields = append(fields, structField{owner: owner, field: fld, prefix: prefix, depth: depth})
+				depths[fld.Names[0].Name] = append(depths[fld.Names[0].Name], depth)
+				continue
+			}
+			if _, name, _, _, err := ast.DetectTypeName(fld.Type); err == nil {
+				name = strings.TrimPrefix(name, "*")
+				name = name[strings.LastIndex(name, ".")+1:]
+				depths[name] = append(depths[name], depth)
+			}
+			embeddedPrefix, ignore, err := ast.ParseEmbeddedTag(tagMarker, fld)
+			if err != nil {
+				report(owner, fld, err)
+				continue
+			}
+			if ignore {
+				report(owner, fld, diagnostic.Skipf("%s.%s is left out: it is tagged to be ignored", modelName, types.ExprString(fld.Type)))
+				continue
+			}
+			embedded, err := owner.Embedded(fld)
+			if err != nil {
+				report(owner, fld, diagnostic.Warnf("%s.%s is left out: %s", modelName, types.ExprString(fld.Type), strings.TrimPrefix(err.Error(), "auto-table: ")))
+				continue
+			}
+			decl := declaration{embedded.Filename, embedded.Name}
+			if embedding[decl] {
+				continue
+			}
+			embedding[decl] = true
+			flatten(embedded, prefix+embeddedPrefix, depth+1)
+			delete(embedding, decl)
+		}
+	}
+	flatten(s, "", 0)
+
+	promoted := fields[:0]
+	for _, f := range fields {
+		name := f.field.Names[0].Name
+		shallower, same := 0, 0
+		for _, depth := range depths[name] {
+			if depth < f.depth {
+				shallower++
+			} else if depth == f.depth {
+				same++
+			}
+		}
+		switch {
+		case shallower > 0:
+			report(f.owner, f.field, diagnostic.Skipf("%s.%s of %s is left out: it is hidden by a field of the same name", modelName, name, f.owner.Name))
+		case same > 1:
+			report(f.owner, f.field, diagnostic.Warnf("%s.%s of %s is left out: more than one embedded struct has the field", modelName, name, f.owner.Name))
+		default:
+			promoted = append(promoted, f)
+		}
+	}
+	return promoted
+}
+
 // autoMakePrimaryFromID will automatically make the primary key if the field it handles is named ID.
 func autoMakePrimaryFromID(isAutoID bool, fld *goast.Field, typeStr string) (isPrimaryKey bool, isAutoIncrement bool, hasID bool, idType string, err error) {
 	// Check if there is a field named id
@@ -188,18 +365,35 @@ func autoMakeForeignRelation(
 	modelName string,
 	fld *goast.Field,
 	typeName string,
+	pkgPath string,
+	isPtr bool,
 	isArray bool,
 	hasID bool,
 	idType string,
-) (typeStr string, fieldName *string, foreignKey *ast.ForeignKey, err error) {
+) (typeStr string, fieldName *string, foreignKey *ast.ForeignKey, skip bool, err error) {
 	// Check to see if it is a dependent model
-	if parent, ok := modelASTMap[strings.ToLower(typeName)]; ok {
-		if isArray {
+	if parent, ok := findModel(modelASTMap, typeName, pkgPath); ok {
+		self := parent == modelASTMap[modelName]
+		if isArray && self {
+			// Children of a tree, like Children []Category, referencing their parent by parent_id
+			f := "ParentID"
+			if p, _, _ := parent.SelfReference(); p != "" || hasField(parent, f) {
+				// The foreign key is made from the pointer to the parent, or declared by the struct.
+				skip = true
+				return
+			}
+			fieldName = &f
+			isPtr = true
+		} else if isArray {
 			// many-to-many
 
 			// Make cross reference table
-			crossReference := fmt.Sprintf("%s_%s", modelName, strings.ToLower(typeName))
+			crossReference := fmt.Sprintf("%s_%s", modelName, strings.ToLower(parent.Name))
 			tableASTMap[crossReference] = &ast.Table{}
+			if self, ok := modelASTMap[modelName]; ok {
+				tableASTMap[crossReference].Filename = self.Filename
+				tableASTMap[crossReference].Pos = self.Position(self.StructType)
+			}
 			dependencyMap[crossReference] = map[string]struct{}{}
 
 			// self field
@@ -213,7 +407,7 @@ func autoMakeForeignRelation(
 				Column: idCandidate,
 			}
 			dependencyMap[crossReference][modelName] = struct{}{}
-			f, fErr := ast.NewField(tagMarker, dialect, modelName, idType, &sFieldName, fld, sForeignKey, true, false)
+			f, fErr := ast.NewField(tagMarker, dialect, crossReference, idType, &sFieldName, fld, sForeignKey, true, false)
 			if fErr != nil {
 				err = fErr
 				return
@@ -224,11 +418,11 @@ func autoMakeForeignRelation(
 			var pTypeStr string
 			var pFieldName *string
 			var pForeignKey *ast.ForeignKey
-			for _, f := range parent.StructType.Fields.List {
+			for _, pf := range structFields(tagMarker, "", parent, nil) {
 				// Set foreign key
-				if strings.ToLower(f.Names[0].Name) == idCandidate {
+				if strings.ToLower(pf.field.Names[0].Name) == idCandidate {
 
-					pTypeStr, _, _, _, err = ast.DetectTypeName(f.Type)
+					pTypeStr, _, _, _, _, err = pf.owner.DetectType(pf.field.Type)
 					if err != nil {
 						return
 					}
@@ -242,7 +436,7 @@ func autoMakeForeignRelation(
 					dependencyMap[crossReference][stringutil.ToSnakeCase(parent.Name)] = struct{}{}
 				}
 			}
-			f, fErr = ast.NewField(tagMarker, dialect, modelName, pTypeStr, pFieldName, fld, pForeignKey, true, false)
+			f, fErr = ast.NewField(tagMarker, dialect, crossReference, pTypeStr, pFieldName, fld, pForeignKey, true, false)
 			if fErr != nil {
 				err = fErr
 				return
@@ -250,24 +444,33 @@ func autoMakeForeignRelation(
 			tableASTMap[crossReference].Fields = append(tableASTMap[crossReference].Fields, f)
 
 			// This is a case of an cross reference table, so no columns are added.
+			skip = true
 			return
-		} else {
-			// one-to-many
-			for _, f := range parent.StructType.Fields.List {
-				// Set foreign key
-				if strings.ToLower(f.Names[0].Name) == idCandidate {
-					pTypeStr, _, _, _, tErr := ast.DetectTypeName(f.Type)
-					if tErr != nil {
-						err = tErr
-						return
-					}
-					typeStr = pTypeStr
+		}
+
+		// one-to-many
+		for _, pf := range structFields(tagMarker, "", parent, nil) {
+			// Set foreign key
+			if strings.ToLower(pf.field.Names[0].Name) == idCandidate {
+				pTypeStr, _, _, _, _, tErr := pf.owner.DetectType(pf.field.Type)
+				if tErr != nil {
+					err = tErr
+					return
+				}
+				typeStr = pTypeStr
+				if isPtr {
+					// A pointer may be nil, like the parent of the root of a tree.
+					typeStr = "*" + pTypeStr
+				}
+				if fieldName == nil {
 					f := fmt.Sprintf("%v%v", stringutil.ToUpperCamelCase(fld.Names[0].Name), stringutil.ToUpperCamelCase(idCandidate))
 					fieldName = &f
-					foreignKey = &ast.ForeignKey{
-						Table:  parent.Name,
-						Column: idCandidate,
-					}
+				}
+				foreignKey = &ast.ForeignKey{
+					Table:  parent.Name,
+					Column: idCandidate,
+				}
+				if !self {
 					dependencyMap[modelName][stringutil.ToSnakeCase(parent.Name)] = struct{}{}
 				}
 			}
@@ -276,6 +479,59 @@ func autoMakeForeignRelation(
 	return
 }
 
+// hasModel reports whether a struct of the name is marked as a model.
+func hasModel(modelASTMap map[string]*ast.StructAST, name string) bool {
+	for _, s := range modelASTMap {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// findModel finds the struct of a type name like User, *User or models.User.
+// The struct is looked up by its table name first like it always has been, then by its name, in the package of pkgPath if it is known.
+func findModel(modelASTMap map[string]*ast.StructAST, typeName string, pkgPath string) (*ast.StructAST, bool) {
+	name := strings.TrimPrefix(typeName, "*")
+	qualifier := ""
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		qualifier, name = name[:i], name[i+1:]
+	}
+	inPackage := func(s *ast.StructAST) bool {
+		if pkgPath != "" && s.PkgPath != "" {
+			return s.PkgPath == pkgPath
+		}
+		return qualifier == "" || s.Package == qualifier
+	}
+	if s, ok := modelASTMap[strings.ToLower(name)]; ok && inPackage(s) {
+		return s, true
+	}
+
+	keys := make([]string, 0, len(modelASTMap))
+	for k := range modelASTMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if s := modelASTMap[k]; s.Name == name && inPackage(s) {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// hasField reports whether the struct declares a field of the name.
+func hasField(s *ast.StructAST, name string) bool {
+	for _, f := range s.StructType.Fields.List {
+		for _, n := range f.Names {
+			if n.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // makeSQLMap generates SQL statements from the table structure according to the dialect.
 func makeSQLMap(dialect d.Dialect, tableASTMap map[string]*ast.Table, tableNames []string) (sqlMap map[string]*SQL) {
 	sqlMap = map[string]*SQL{} // map[tableName]schema
@@ -296,29 +552,72 @@ func makeSQLMap(dialect d.Dialect, tableASTMap map[string]*ast.Table, tableNames
 			Fields:      fields,
 			PrimaryKeys: pkColumns,
 			ForeignKeys: fksColumns,
+			Indexes:     ast.MakeIndexes(name, tbl.Fields),
 			Option:      tbl.Option,
 		}
-		createTableSQL := strings.Join(dialect.CreateTableSQL(t), "")
-		dropTableSQL := strings.Join(dialect.DropTableSQL(t), "")
-		findAllSQL := strings.Join(dialect.FindAllSQL(t), "")
-		findSQL := strings.Join(dialect.FindSQL(t), "")
-		createSQL := strings.Join(dialect.CreateSQL(t), "")
-		deleteSQL := strings.Join(dialect.DeleteSQL(t), "")
-		updateSQL := strings.Join(dialect.UpdateSQL(t), "")
+		createTableSQL := strings.Join(CreateTableSQL(dialect, t), "\n")
+		dropTableSQL := strings.Join(DropTableSQL(dialect, t), "\n")
+		findAllSQL := strings.Join(dialect.FindAllSQL(t), "\n")
+		findSQL := strings.Join(dialect.FindSQL(t), "\n")
+		createSQL := strings.Join(dialect.CreateSQL(t), "\n")
+		deleteSQL := strings.Join(dialect.DeleteSQL(t), "\n")
+		updateSQL := strings.Join(dialect.UpdateSQL(t), "\n")
+
+		record := Record{
+			FindAll: findAllSQL,
+			Find:    findSQL,
+			Create:  createSQL,
+			Delete:  deleteSQL,
+			Update:  updateSQL,
+			FindBy:  map[string]string{},
+		}
+		if querier, ok := dialect.(d.RecordQuerier); ok {
+			record.Upsert = strings.Join(querier.UpsertSQL(t), "\n")
+			record.BatchCreate = strings.Join(querier.BatchCreateSQL(t, BatchRows), "\n")
+			record.Paginate = strings.Join(querier.PaginateSQL(t), "\n")
+			for _, index := range t.Indexes {
+				if index.Unique {
+					record.FindBy[index.Name] = strings.Join(querier.FindByIndexSQL(t, index), "\n")
+				}
+			}
+		}
 
 		sqlMap[name] = &SQL{
+			Schema: t,
+			Fields: tbl.Fields,
+			Source: Source{
+				Struct:       tbl.Struct,
+				Filename:     tbl.Filename,
+				Pos:          tbl.Pos,
+				Package:      tbl.Package,
+				Children:     tbl.Children,
+				ChildrenType: tbl.ChildrenType,
+			},
 			Table: Table{
 				Create: createTableSQL,
 				Drop:   dropTableSQL,
 			},
-			Record: Record{
-				FindAll: findAllSQL,
-				Find:    findSQL,
-				Create:  createSQL,
-				Delete:  deleteSQL,
-				Update:  updateSQL,
-			},
+			Record: record,
 		}
 	}
+	addRelations(dialect, sqlMap)
 	return
 }
+
+// CreateTableSQL generates the statements that create the table and its indexes.
+func CreateTableSQL(dialect d.Dialect, t d.Table) []string {
+	queries := dialect.CreateTableSQL(t)
+	for _, index := range t.Indexes {
+		queries = append(queries, dialect.CreateIndexSQL(index)...)
+	}
+	return queries
+}
+
+// DropTableSQL generates the statements that drop the indexes of the table and the table itself.
+func DropTableSQL(dialect d.Dialect, t d.Table) []string {
+	var queries []string
+	for i := len(t.Indexes) - 1; i >= 0; i-- {
+		queries = append(queries, dialect.DropIndexSQL(t.Indexes[i])...)
+	}
+	return append(queries, dialect.DropTableSQL(t)...)
+}
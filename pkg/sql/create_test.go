@@ -0,0 +1,145 @@
+package sql
+
+import (
+	"fmt"
+	"github.com/hourglasshoro/auto-table/pkg/ast"
+	"github.com/hourglasshoro/auto-table/pkg/diagnostic"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStructFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		want  []string // prefix and name of the fields, with the depth they are promoted through
+		diags []string // severity and message
+	}{
+		{
+			name: "plain fields",
+			src: `type User struct {
+	ID   int64
+	Name string
+}`,
+			want: []string{"ID/0", "Name/0"},
+		},
+		{
+			name: "embedded struct flattened",
+			src: `type Timestamps struct {
+	DeletedAt *time.Time
+}
+
+type User struct {
+	ID int64
+	Timestamps
+}`,
+			want: []string{"ID/0", "DeletedAt/1"},
+		},
+		{
+			name: "prefixes of nested embedded structs",
+			src: "type Geo struct {\n" +
+				"\tLat float64\n" +
+				"}\n\n" +
+				"type Address struct {\n" +
+				"\tCity string\n" +
+				"\tGeo `test:\"prefix\"`\n" +
+				"}\n\n" +
+				"type User struct {\n" +
+				"\tID int64\n" +
+				"\tAddress `test:\"prefix:home_\"`\n" +
+				"}",
+			want: []string{"ID/0", "home_City/1", "home_geo_Lat/2"},
+		},
+		{
+			name: "ignored embedded struct",
+			src: "type Secret struct {\n" +
+				"\tToken string\n" +
+				"}\n\n" +
+				"type User struct {\n" +
+				"\tID int64\n" +
+				"\tSecret `test:\"-\"`\n" +
+				"}",
+			want:  []string{"ID/0"},
+			diags: []string{"skip: user.Secret is left out: it is tagged to be ignored"},
+		},
+		{
+			name: "field hidden by a shallower field",
+			src: `type Base struct {
+	ID   int64
+	Name string
+}
+
+type User struct {
+	Base
+	Name string
+}`,
+			want:  []string{"ID/1", "Name/0"},
+			diags: []string{"skip: user.Name of Base is left out: it is hidden by a field of the same name"},
+		},
+		{
+			name: "field of two embedded structs at the same depth",
+			src: `type A struct {
+	Note string
+}
+
+type B struct {
+	Note string
+}
+
+type User struct {
+	ID int64
+	A
+	B
+}`,
+			want: []string{"ID/0"},
+			diags: []string{
+				"warning: user.Note of A is left out: more than one embedded struct has the field",
+				"warning: user.Note of B is left out: more than one embedded struct has the field",
+			},
+		},
+		{
+			name: "struct embedding itself",
+			src: `type User struct {
+	ID int64
+	*User
+}`,
+			want: []string{"ID/0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "models.go")
+			src := "package models\n\nimport \"time\"\n\nvar _ time.Time\n\n" + strings.Replace(tt.src, "type User struct", "//+test\ntype User struct", 1)
+			if err := os.WriteFile(filename, []byte(src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			structASTMap, err := ast.MakeStructASTMap(filename, "+test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, ok := structASTMap["user"]
+			if !ok {
+				t.Fatalf("MakeStructASTMap() = %v, want the user struct", structASTMap)
+			}
+
+			var diags diagnostic.List
+			var got []string
+			for _, f := range structFields("test", "user", s, &diags) {
+				got = append(got, fmt.Sprintf("%s%s/%d", f.prefix, f.field.Names[0].Name, f.depth))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("structFields() = %v, want %v", got, tt.want)
+			}
+			var gotDiags []string
+			for _, d := range diags {
+				gotDiags = append(gotDiags, fmt.Sprintf("%s: %s", d.Severity, d.Message))
+			}
+			if !reflect.DeepEqual(gotDiags, tt.diags) {
+				t.Errorf("diagnostics = %q, want %q", gotDiags, tt.diags)
+			}
+		})
+	}
+}
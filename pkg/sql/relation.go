@@ -0,0 +1,159 @@
+package sql
+
+import (
+	"github.com/hourglasshoro/auto-table/pkg/ast"
+	d "github.com/hourglasshoro/auto-table/pkg/dialect"
+	"github.com/hourglasshoro/auto-table/pkg/utils"
+	"github.com/naoina/go-stringutil"
+	"sort"
+	"strings"
+)
+
+type RelationKind int
+
+const (
+	RelationParent   RelationKind = iota // The record a foreign key of the table references
+	RelationChildren                     // The records whose foreign key references the table
+	RelationMany                         // The records linked to the table by a cross reference table
+)
+
+// Relation is a statement reading the records related to a record of the table.
+type Relation struct {
+	Kind       RelationKind
+	Name       string // Like Author for author_id, MicropostsByAuthor for the children of a user or Tags for micropost_tag
+	Table      string // Table of the records the statement reads
+	Column     string // Foreign key column, in the table for RelationParent, in Table for RelationChildren and in Through for RelationMany
+	References string // Column the foreign key references, in the table for RelationChildren and in Table otherwise
+	Through    string // Cross reference table of RelationMany
+	From       string // Column of Through referencing the table, for RelationMany
+	Key        string // Column of the table whose value the statement takes, empty if it takes the primary key like Record.Find
+	Field      string // Struct field of the table the related records are loaded into, empty if the struct has none
+	FieldType  string // Declared type of Field, like User, *User or []Tag
+	SQL        string
+}
+
+// foreignKey is a foreign key column of a table.
+type foreignKey struct {
+	column    string
+	field     *ast.Field
+	reference d.ForeignKey // Names of the referenced table and column
+}
+
+// addRelations adds the statements following the foreign keys to the tables on both of their sides.
+func addRelations(dialect d.Dialect, sqlMap map[string]*SQL) {
+	querier, ok := dialect.(d.RelationQuerier)
+	if !ok {
+		return
+	}
+	tableNames := make([]string, 0, len(sqlMap))
+	for name := range sqlMap {
+		tableNames = append(tableNames, name)
+	}
+	sort.Strings(tableNames)
+
+	for _, name := range tableNames {
+		s := sqlMap[name]
+		fks := foreignKeys(s)
+		if s.Source.Struct == "" {
+			if len(fks) == 2 {
+				// fks[0] references the struct declaring the slice field the cross reference table is made from.
+				addManyRelation(querier, sqlMap, s, fks[0], fks[1], true)
+				addManyRelation(querier, sqlMap, s, fks[1], fks[0], false)
+			}
+			continue
+		}
+		childrenLoaded := false
+		for _, fk := range fks {
+			parent, ok := sqlMap[fk.reference.Table]
+			if !ok || parent.Source.Struct == "" {
+				continue
+			}
+			relation := &Relation{
+				Kind:       RelationParent,
+				Name:       relationName(fk.column),
+				Table:      parent.Schema.Name,
+				Column:     fk.column,
+				References: fk.reference.Column,
+				SQL:        strings.Join(querier.ParentSQL(s.Schema, fk.column, parent.Schema, fk.reference.Column), "\n"),
+			}
+			if fk.field.Name != fk.field.StructField && strings.TrimPrefix(fk.field.StructType, "*") == parent.Source.Struct {
+				relation.Field, relation.FieldType = fk.field.StructField, fk.field.StructType
+			}
+			s.Relations = append(s.Relations, relation)
+
+			children := utils.Plural(s.Source.Struct)
+			if by := relationName(fk.column); by != parent.Source.Struct {
+				children += "By" + by
+			}
+			childrenRelation := &Relation{
+				Kind:       RelationChildren,
+				Name:       children,
+				Table:      s.Schema.Name,
+				Column:     fk.column,
+				References: fk.reference.Column,
+				Key:        fk.reference.Column,
+				SQL:        strings.Join(querier.ChildrenSQL(s.Schema, fk.column), "\n"),
+			}
+			// The children of a tree are loaded by the first foreign key referencing the table itself from the parent pointer or the children slice.
+			if parent == s && s.Source.Children != "" && !childrenLoaded && (relation.Field != "" || fk.field.StructField == s.Source.Children) {
+				childrenRelation.Field, childrenRelation.FieldType = s.Source.Children, s.Source.ChildrenType
+				childrenLoaded = true
+			}
+			parent.Relations = append(parent.Relations, childrenRelation)
+		}
+	}
+}
+
+// addManyRelation adds the statement reading the records of the table to references to the table from references, linked by the cross reference table.
+// The slice field the cross reference table is made from is loaded by the statement if declared is true.
+func addManyRelation(querier d.RelationQuerier, sqlMap map[string]*SQL, through *SQL, from foreignKey, to foreignKey, declared bool) {
+	owner, ok := sqlMap[from.reference.Table]
+	if !ok || owner.Source.Struct == "" {
+		return
+	}
+	target, ok := sqlMap[to.reference.Table]
+	if !ok || target.Source.Struct == "" {
+		return
+	}
+	relation := &Relation{
+		Kind:       RelationMany,
+		Name:       utils.Plural(relationName(to.column)),
+		Table:      target.Schema.Name,
+		Column:     to.column,
+		References: to.reference.Column,
+		Through:    through.Schema.Name,
+		From:       from.column,
+		Key:        from.reference.Column,
+		SQL:        strings.Join(querier.ThroughSQL(target.Schema, to.reference.Column, through.Schema, from.column, to.column), "\n"),
+	}
+	if elem := strings.TrimPrefix(to.field.StructType, "[]"); declared && elem != to.field.StructType && strings.TrimPrefix(elem, "*") == target.Source.Struct {
+		relation.Field, relation.FieldType = to.field.StructField, to.field.StructType
+	}
+	owner.Relations = append(owner.Relations, relation)
+}
+
+// foreignKeys returns the foreign key columns of the table in the order of the columns.
+func foreignKeys(s *SQL) (fks []foreignKey) {
+	for i, f := range s.Fields {
+		if f.ForeignKey == nil {
+			continue
+		}
+		fks = append(fks, foreignKey{
+			column: s.Schema.Fields[i].Name,
+			field:  f,
+			reference: d.ForeignKey{
+				Table:  stringutil.ToSnakeCase(f.ForeignKey.Table),
+				Column: stringutil.ToSnakeCase(f.ForeignKey.Column),
+			},
+		})
+	}
+	return
+}
+
+// relationName names the relation of a foreign key column after the column, like Author for author_id.
+func relationName(column string) string {
+	if trimmed := strings.TrimSuffix(column, "_id"); trimmed != "" {
+		column = trimmed
+	}
+	return stringutil.ToUpperCamelCase(column)
+}
@@ -0,0 +1,82 @@
+package sql
+
+import (
+	"fmt"
+	d "github.com/hourglasshoro/auto-table/pkg/dialect"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const relationSource = `package models
+
+//+test
+type User struct {
+	ID int64
+}
+
+//+test
+type Micropost struct {
+	ID     int64
+	Author User
+	Editor *User
+	Tag    []Tag
+}
+
+//+test
+type Tag struct {
+	ID int64
+}
+
+//+test
+type Category struct {
+	ID       int64
+	Parent   *Category
+	Children []Category
+}
+`
+
+func TestAddRelations(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "models.go")
+	if err := os.WriteFile(filename, []byte(relationSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sqlMap, _, _, err := CreateSQL(d.NewSQLite(), true, "+test", "test", []string{filename})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[RelationKind]string{RelationParent: "parent", RelationChildren: "children", RelationMany: "many"}
+	got := map[string][]string{}
+	for name, s := range sqlMap {
+		for _, r := range s.Relations {
+			relation := fmt.Sprintf("%s %s: %s.%s", kinds[r.Kind], r.Name, r.Table, r.Column)
+			if r.Field != "" {
+				relation += fmt.Sprintf(" into %s %s", r.Field, r.FieldType)
+			}
+			got[name] = append(got[name], relation)
+		}
+	}
+	want := map[string][]string{
+		"category": {
+			"parent Parent: category.parent_id into Parent *Category",
+			"children CategoriesByParent: category.parent_id into Children []Category",
+		},
+		"micropost": {
+			"parent Author: user.author_id into Author User",
+			"parent Editor: user.editor_id into Editor *User",
+			"many Tags: tag.tag_id into Tag []Tag",
+		},
+		"tag": {"many Microposts: micropost.micropost_id"},
+		"user": {
+			"children MicropostsByAuthor: micropost.author_id",
+			"children MicropostsByEditor: micropost.editor_id",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("relations = %q, want %q", got, want)
+	}
+	if got, want := sqlMap["micropost"].Relations[2].SQL, `SELECT t."id", t."created_at", t."updated_at" FROM "tag" t JOIN "micropost_tag" x ON x."tag_id" = t."id" WHERE x."micropost_id" = ?;`; got != want {
+		t.Errorf("SQL of Tags =\n%s\nwant\n%s", got, want)
+	}
+}
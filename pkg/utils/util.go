@@ -1,5 +1,7 @@
 package utils
 
+import "strings"
+
 func InStrings(a []string, s string) bool {
 	for _, v := range a {
 		if v == s {
@@ -12,3 +14,15 @@ func InStrings(a []string, s string) bool {
 func IsSpace(b byte) bool {
 	return b == ' ' || b == '\t'
 }
+
+// Plural returns the English plural of a name, like Users for User.
+func Plural(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}